@@ -0,0 +1,517 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Lynricsy/SimpleArchiver/internal/archiver"
+	"github.com/Lynricsy/SimpleArchiver/internal/config"
+	"github.com/Lynricsy/SimpleArchiver/internal/i18n"
+)
+
+// batchListSize 批量任务列表最多显示的行数
+const batchListSize = 10
+
+// batchProgressMsg 批量任务进度消息
+type batchProgressMsg archiver.JobUpdate
+
+// batchDoneMsg 批量任务完成消息
+type batchDoneMsg struct {
+	results []archiver.BatchResult
+}
+
+// selectBatch 以 sources 开始批量任务：压缩模式每项单独压缩，解压模式解压所有归档
+func (m model) selectBatch(sources []string) (tea.Model, tea.Cmd) {
+	if len(sources) == 0 {
+		m.selectError = i18n.T().NothingMarked
+		return m, nil
+	}
+
+	m.batch = true
+	m.batchSources = slices.Clone(sources)
+	m.selectedPath = commonDir(sources)
+	m.selectedPaths = nil
+	m.preset = nil
+	m.password = ""
+	m.usePassword = false
+
+	if m.mode == modeExtract {
+		// 批量解压使用自动检测的文件名编码，加密的归档会单独失败
+		m.extractMode = m.cfg.ExtractLayout()
+		m.charsetCursor = 0
+		if err := m.planBatchOutputs(); err != nil {
+			m.selectError = err.Error()
+			return m, nil
+		}
+		m.state = stateConfirm
+		return m, nil
+	}
+
+	m.state = stateSelectFormat
+	return m, nil
+}
+
+// planBatchOutputs 按命名模板为每个批量任务生成输出路径
+func (m *model) planBatchOutputs() error {
+	outputs := make([]string, len(m.batchSources))
+	owners := make(map[string]string, len(m.batchSources))
+
+	for i, source := range m.batchSources {
+		var output string
+		var err error
+		if m.mode == modeExtract {
+			baseName := archiveBaseName(source)
+			output, err = outputFromTemplate(m.cfg.ExtractNameTemplate(), filepath.Dir(source), source, baseName, strings.TrimPrefix(filepath.Base(source), baseName))
+			if m.extractMode == config.ExtractModeHere {
+				output = filepath.Dir(source)
+			}
+		} else {
+			dir := m.cfg.OutputDir()
+			if dir == "" {
+				dir = filepath.Dir(source)
+			}
+			output, err = outputFromTemplate(m.cfg.CompressNameTemplate(), dir, source, filepath.Base(source), m.selectedFormat.Extension)
+		}
+		if err != nil {
+			return err
+		}
+
+		// 解压到此处时多个归档可以共用同一个目录
+		if other, ok := owners[output]; ok && m.extractMode != config.ExtractModeHere {
+			return fmt.Errorf("%s 与 %s 的输出路径相同: %s", filepath.Base(other), filepath.Base(source), output)
+		}
+		owners[output] = source
+		outputs[i] = output
+	}
+
+	m.batchOutputs = outputs
+	return nil
+}
+
+// batchJobs 生成每个批量任务的压缩或解压选项
+func (m model) batchJobs() []archiver.BatchJob {
+	excludes, respectIgnoreFiles := m.selectedExcludes()
+
+	jobs := make([]archiver.BatchJob, len(m.batchSources))
+	for i, source := range m.batchSources {
+		jobs[i].Name = filepath.Base(source)
+		if m.mode == modeExtract {
+			jobs[i].Extract = &archiver.ExtractOptions{
+				Source:               source,
+				Output:               m.batchOutputs[i],
+				NormalizeNFC:         true,
+				SanitizeFor:          runtime.GOOS,
+				DetectCaseCollisions: runtime.GOOS == "windows" || runtime.GOOS == "darwin",
+				Smart:                m.extractMode == config.ExtractModeSmart,
+			}
+			extractTuning(m.cfg, jobs[i].Extract)
+		} else {
+			jobs[i].Compress = &archiver.CompressOptions{
+				Source:             source,
+				Output:             m.batchOutputs[i],
+				Format:             m.selectedFormat.Extension,
+				Excludes:           excludes,
+				Password:           m.password,
+				Level:              m.cfg.Defaults.Level,
+				RespectIgnoreFiles: respectIgnoreFiles,
+			}
+			compressTuning(m.cfg, jobs[i].Compress)
+		}
+	}
+	return jobs
+}
+
+// updateBatchConfirm 更新批量任务确认状态
+func (m model) updateBatchConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc", "n":
+		switch {
+		case m.mode == modeExtract:
+			m.state = stateSelectFile
+		case m.selectedFormat.Extension == ".zip":
+			m.state = stateInputPassword
+		default:
+			m.state = stateSelectExcludes
+		}
+
+	case "y", "enter":
+		m.speedHistory = make([]float64, 0, 30)
+		m.lastBytes = 0
+		m.lastTime = time.Now()
+		m.startTime = time.Now()
+		m.currentSpeed = 0
+		m.avgSpeed = 0
+
+		m.state = stateBatchRunning
+		return m, tea.Batch(
+			m.startBatch(),
+			tea.Tick(200*time.Millisecond, func(t time.Time) tea.Msg {
+				return tickMsg(t)
+			}),
+		)
+	}
+
+	return m, nil
+}
+
+// startBatch 开始批量任务
+func (m *model) startBatch() tea.Cmd {
+	m.progressChan = make(chan interface{}, 100)
+	progressChan := m.progressChan
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.operationCtx = ctx
+	m.operationCancel = cancel
+
+	jobs := m.batchJobs()
+	m.batchResults = nil
+	m.batchRows = make([]archiver.JobUpdate, len(jobs))
+	for i := range m.batchRows {
+		m.batchRows[i] = archiver.JobUpdate{Index: i, Status: archiver.JobPending}
+	}
+	workers := batchWorkerCount(m.cfg)
+
+	batchCmd := func() tea.Msg {
+		defer close(progressChan)
+
+		results := archiver.RunBatch(ctx, jobs, workers, func(u archiver.JobUpdate) {
+			// 进度更新可以丢弃，状态变化必须送达
+			if u.Status == archiver.JobRunning && u.Processed > 0 {
+				select {
+				case progressChan <- batchProgressMsg(u):
+				default:
+				}
+				return
+			}
+			select {
+			case progressChan <- batchProgressMsg(u):
+			case <-ctx.Done():
+			}
+		})
+		return batchDoneMsg{results: results}
+	}
+
+	return tea.Batch(
+		batchCmd,
+		listenProgressChan(progressChan),
+	)
+}
+
+// applyJobUpdate 记录任务的最新状态，已结束的任务不再更新
+func (m *model) applyJobUpdate(u archiver.JobUpdate) {
+	if u.Index < 0 || u.Index >= len(m.batchRows) {
+		return
+	}
+	row := &m.batchRows[u.Index]
+	if row.Status != archiver.JobPending && row.Status != archiver.JobRunning {
+		return
+	}
+	*row = u
+}
+
+// batchStatuses 返回每个任务的状态，完成后以结果为准
+func (m model) batchStatuses() []string {
+	statuses := make([]string, len(m.batchSources))
+	for i := range statuses {
+		switch {
+		case i < len(m.batchResults):
+			statuses[i] = m.batchResults[i].Status
+		case i < len(m.batchRows):
+			statuses[i] = m.batchRows[i].Status
+		default:
+			statuses[i] = archiver.JobPending
+		}
+	}
+	return statuses
+}
+
+// batchCounts 统计完成、失败和取消的任务数
+func (m model) batchCounts() (done, failed, canceled int) {
+	for _, status := range m.batchStatuses() {
+		switch status {
+		case archiver.JobDone:
+			done++
+		case archiver.JobFailed:
+			failed++
+		case archiver.JobCanceled:
+			canceled++
+		}
+	}
+	return done, failed, canceled
+}
+
+// batchPercent 返回所有任务的总体进度，已结束的任务计为完成
+func (m model) batchPercent() float64 {
+	if len(m.batchRows) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, row := range m.batchRows {
+		switch row.Status {
+		case archiver.JobPending:
+		case archiver.JobRunning:
+			if row.Total > 0 {
+				sum += float64(row.Processed) / float64(row.Total)
+			}
+		default:
+			sum++
+		}
+	}
+	return sum / float64(len(m.batchRows))
+}
+
+// batchWindow 返回任务列表的显示范围，从第一个未结束的任务附近开始
+func (m model) batchWindow(statuses []string) (start, end int) {
+	active := slices.IndexFunc(statuses, func(status string) bool {
+		return status == archiver.JobPending || status == archiver.JobRunning
+	})
+	if active > 2 {
+		start = active - 2
+	}
+	end = min(start+batchListSize, len(statuses))
+	start = max(0, end-batchListSize)
+	return start, end
+}
+
+// jobIcon 返回任务状态图标
+func (m model) jobIcon(status string) string {
+	switch status {
+	case archiver.JobRunning:
+		return m.spinner.View()
+	case archiver.JobDone:
+		return successStyle.Render(iconSuccess)
+	case archiver.JobFailed:
+		return errorStyle.Render(iconError)
+	case archiver.JobCanceled:
+		return warningStyle.Render(iconWarning)
+	}
+	return disabledStyle.Render(iconCheckboxOff)
+}
+
+// truncateRunes 将字符串截断到 n 个字符，超出部分用 ... 表示
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// relativeTo 返回 path 相对于 dir 的路径，不在 dir 下时返回完整路径
+func relativeTo(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
+
+// viewBatchConfirm 渲染批量任务确认视图
+func (m model) viewBatchConfirm() string {
+	t := i18n.T()
+	var sb strings.Builder
+
+	if m.mode == modeExtract {
+		sb.WriteString(titleStyle.Render(iconCheck + "  " + t.BatchExtractTitle))
+	} else {
+		sb.WriteString(titleStyle.Render(iconCheck + "  " + t.BatchCompressTitle))
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString(statLabelStyle.Render(iconFile + "  " + t.BatchJobs))
+	sb.WriteString(statValueStyle.Render(fmt.Sprintf("%d", len(m.batchSources))))
+	sb.WriteString("\n")
+
+	if m.mode == modeExtract {
+		sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.ExtractModeLabel))
+		sb.WriteString(infoStyle.Render(extractModeText(m.extractMode)))
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString(statLabelStyle.Render(iconCompress + "  " + t.CompressFormat))
+		sb.WriteString(infoStyle.Render(m.selectedFormat.Name))
+		sb.WriteString("\n")
+
+		if m.selectedFormat.Extension == ".zip" {
+			sb.WriteString(statLabelStyle.Render(iconKey + "  " + t.PasswordProtect))
+			if m.usePassword {
+				sb.WriteString(successStyle.Render(iconLock + " " + t.AESEncrypted))
+			} else {
+				sb.WriteString(disabledStyle.Render(iconUnlock + " " + t.PasswordNone))
+			}
+			sb.WriteString("\n")
+		}
+
+		excludes, _ := m.selectedExcludes()
+		sb.WriteString(statLabelStyle.Render(iconWarning + "  " + t.ExcludeRules))
+		sb.WriteString(warningStyle.Render(fmt.Sprintf(t.PatternsCount, len(excludes))))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(statLabelStyle.Render(iconRocket + "  " + t.BatchWorkers))
+	sb.WriteString(infoStyle.Render(fmt.Sprintf("%d", min(batchWorkerCount(m.cfg), len(m.batchSources)))))
+	sb.WriteString("\n\n")
+
+	// 任务列表
+	for i, source := range m.batchSources {
+		if i >= batchListSize {
+			sb.WriteString(subtitleStyle.Render(fmt.Sprintf("  "+t.RenamedMore, len(m.batchSources)-i)))
+			sb.WriteString("\n")
+			break
+		}
+		output := relativeTo(filepath.Dir(source), m.batchOutputs[i])
+		if m.mode == modeExtract {
+			output += "/"
+		}
+		sb.WriteString(normalStyle.Render("  " + truncateRunes(filepath.Base(source), 30)))
+		sb.WriteString(subtitleStyle.Render(" → " + output))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	if m.mode == modeExtract {
+		sb.WriteString(successStyle.Render(t.ConfirmStartExtract))
+	} else {
+		sb.WriteString(successStyle.Render(t.ConfirmStart))
+	}
+
+	return highlightBorderStyle.Render(sb.String())
+}
+
+// viewBatchRunning 渲染批量任务进度视图：总体进度条和每个任务一行
+func (m model) viewBatchRunning() string {
+	t := i18n.T()
+	var sb strings.Builder
+
+	if m.mode == modeExtract {
+		sb.WriteString(titleStyle.Render(iconRocket + "  " + t.BatchExtracting))
+	} else {
+		sb.WriteString(titleStyle.Render(iconRocket + "  " + t.BatchCompressing))
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString(m.progress.ViewAs(m.batchPercent()))
+	sb.WriteString("\n\n")
+
+	done, failed, canceled := m.batchCounts()
+	sb.WriteString(statLabelStyle.Render(t.Progress))
+	sb.WriteString(statValueStyle.Render(fmt.Sprintf(t.BatchSummary, done, failed, canceled, len(m.batchSources))))
+	sb.WriteString("\n")
+
+	if !m.startTime.IsZero() {
+		sb.WriteString(statLabelStyle.Render(t.ElapsedTime))
+		sb.WriteString(statValueStyle.Render(formatDuration(time.Since(m.startTime))))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	statuses := m.batchStatuses()
+	start, end := m.batchWindow(statuses)
+	for i := start; i < end; i++ {
+		row := m.batchRows[i]
+		name := fmt.Sprintf("%-24s", truncateRunes(filepath.Base(m.batchSources[i]), 24))
+		sb.WriteString(m.jobIcon(row.Status) + " " + normalStyle.Render(name))
+
+		switch row.Status {
+		case archiver.JobRunning:
+			if row.Total > 0 {
+				sb.WriteString(infoStyle.Render(fmt.Sprintf(" %3.0f%%", float64(row.Processed)/float64(row.Total)*100)))
+			}
+			if row.CurrentFile != "" {
+				sb.WriteString(subtitleStyle.Render(" " + truncateRunes(row.CurrentFile, 30)))
+			}
+		case archiver.JobFailed:
+			if row.Err != nil {
+				sb.WriteString(errorStyle.Render(" " + truncateRunes(row.Err.Error(), 40)))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	if len(statuses) > end {
+		sb.WriteString(subtitleStyle.Render(fmt.Sprintf("  "+t.RenamedMore, len(statuses)-end)))
+		sb.WriteString("\n")
+	}
+
+	return highlightBorderStyle.Render(sb.String())
+}
+
+// viewBatchDone 渲染批量任务完成视图
+func (m model) viewBatchDone() string {
+	t := i18n.T()
+	var sb strings.Builder
+
+	done, failed, canceled := m.batchCounts()
+	if failed > 0 {
+		sb.WriteString(warningStyle.Render(iconWarning + "  " + fmt.Sprintf(t.BatchDoneFailed, failed)))
+	} else {
+		sb.WriteString(successStyle.Render(iconSuccess + "  " + t.BatchDone))
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.BatchJobs))
+	sb.WriteString(statValueStyle.Render(fmt.Sprintf(t.BatchSummary, done, failed, canceled, len(m.batchSources))))
+	sb.WriteString("\n")
+
+	// 汇总成功任务的统计
+	var files int
+	var size, compressed int64
+	for _, result := range m.batchResults {
+		if result.CompressStats != nil {
+			files += result.CompressStats.TotalFiles
+			size += result.CompressStats.TotalSize
+			compressed += result.CompressStats.CompressedSize
+		}
+		if result.ExtractStats != nil {
+			files += result.ExtractStats.TotalFiles
+			size += result.ExtractStats.ExtractedSize
+		}
+	}
+	if m.mode == modeExtract {
+		sb.WriteString(statLabelStyle.Render(iconFile + "  " + t.ExtractedFiles))
+		sb.WriteString(statValueStyle.Render(fmt.Sprintf("%d", files)))
+		sb.WriteString("\n")
+		sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.ExtractedSize))
+		sb.WriteString(successStyle.Render(formatFileSize(size)))
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString(statLabelStyle.Render(iconFile + "  " + t.CompressedFiles))
+		sb.WriteString(statValueStyle.Render(fmt.Sprintf("%d", files)))
+		sb.WriteString("\n")
+		sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.OriginalSize))
+		sb.WriteString(infoStyle.Render(formatFileSize(size)))
+		sb.WriteString("\n")
+		sb.WriteString(statLabelStyle.Render(iconCompress + "  " + t.CompressedSize))
+		sb.WriteString(successStyle.Render(formatFileSize(compressed)))
+		sb.WriteString("\n")
+	}
+
+	// 失败的任务及原因
+	if failed > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(statLabelStyle.Render(iconWarning + "  " + t.BatchFailedJobs))
+		sb.WriteString("\n")
+		shown := 0
+		for i, result := range m.batchResults {
+			if result.Status != archiver.JobFailed {
+				continue
+			}
+			if shown == batchListSize {
+				sb.WriteString(subtitleStyle.Render(fmt.Sprintf("  "+t.RenamedMore, failed-shown)))
+				sb.WriteString("\n")
+				break
+			}
+			sb.WriteString(errorStyle.Render("  " + iconError + " " + filepath.Base(m.batchSources[i])))
+			sb.WriteString(subtitleStyle.Render(": " + truncateRunes(result.Err.Error(), 60)))
+			sb.WriteString("\n")
+			shown++
+		}
+	}
+
+	return highlightBorderStyle.Render(sb.String())
+}
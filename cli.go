@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Lynricsy/SimpleArchiver/internal/archiver"
+	"github.com/Lynricsy/SimpleArchiver/internal/config"
+)
+
+// usage 命令行用法
+const usage = `Usage:
+  simple-archiver                          Start the interactive UI
+  simple-archiver run <preset>             Run a saved preset without the UI
+  simple-archiver run                      List saved presets
+  simple-archiver list <archive> [entry...]
+                                           List archive entries (mode, size, mtime, name)
+  simple-archiver extract <archive> [-o dir] [entry...]
+                                           Extract the archive, or only the given entries
+                                           and directories, into dir (default: .)
+  simple-archiver index <archive.tar.gz>   Build a random-access index next to the archive
+                                           so list and extract of single entries can seek
+  simple-archiver config init [--yaml] [--force]
+                                           Write a commented default config file
+`
+
+// runCommand 执行命令行子命令，返回进程退出码
+func runCommand(args []string) int {
+	switch {
+	case args[0] == "run":
+		return runPreset(args[1:])
+	case args[0] == "list":
+		return runList(args[1:])
+	case args[0] == "extract":
+		return runExtract(args[1:])
+	case args[0] == "index":
+		return runIndex(args[1:])
+	case len(args) >= 2 && args[0] == "config" && args[1] == "init":
+		return runConfigInit(args[2:])
+	case len(args) == 1 && (args[0] == "-h" || args[0] == "--help" || args[0] == "help"):
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %v\n\n%s", args, usage)
+		return 2
+	}
+}
+
+// runConfigInit 写入默认配置文件
+func runConfigInit(args []string) int {
+	useYAML, force := false, false
+	for _, arg := range args {
+		switch arg {
+		case "--yaml":
+			useYAML = true
+		case "--force":
+			force = true
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown option: %s\n\n%s", arg, usage)
+			return 2
+		}
+	}
+
+	path, err := config.WriteDefault(useYAML, force)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(path)
+	return 0
+}
+
+// runPreset 运行保存的预设，不带名称时列出所有预设
+func runPreset(args []string) int {
+	if len(args) == 0 {
+		presets, err := config.LoadPresets()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, p := range presets {
+			fmt.Printf("%s\t%s -> %s\n", p.Name, strings.Join(p.SourceList(), ", "), p.Format)
+		}
+		return 0
+	}
+	if len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "Unknown option: %v\n\n%s", args[1:], usage)
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	preset, err := config.FindPreset(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	opts, err := presetOptions(preset, cfg, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if opts.Format == ".7z" && !archiver.Is7zAvailable() {
+		fmt.Fprintln(os.Stderr, "7z command not found. Please install p7zip.")
+		return 1
+	}
+
+	// Ctrl+C 取消压缩
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	source := opts.Source
+	if len(opts.Sources) > 0 {
+		source = strings.Join(opts.Sources, ", ")
+	}
+	fmt.Fprintf(os.Stderr, "%s -> %s\n", source, opts.Output)
+	stats, err := archiver.Compress(ctx, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("%s\n%d files, %s -> %s, %d excluded\n",
+		opts.Output, stats.ProcessedFiles, formatFileSize(stats.TotalSize), formatFileSize(stats.CompressedSize), stats.ExcludedFiles)
+	if stats.ZstdDict != nil {
+		fmt.Printf("zstd dict: %s\n", zstdDictSummary(*stats.ZstdDict))
+	}
+	if stats.GzipIndex != nil {
+		fmt.Printf("gzip index: %s\n", gzipIndexSummary(*stats.GzipIndex))
+	}
+	return 0
+}
+
+// runList 列出归档中的条目，指定条目时只列出这些条目及目录下的内容
+func runList(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Missing archive\n\n%s", usage)
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	opts := archiver.ExtractOptions{Source: args[0], Charset: archiver.CharsetAuto, Entries: args[1:]}
+	extractTuning(cfg, &opts)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	entries, err := archiver.List(ctx, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, e := range entries {
+		name := e.Name
+		if e.Link != "" {
+			name += " -> " + e.Link
+		}
+		fmt.Printf("%s %10d %s %s\n", e.Mode, e.Size, e.ModTime.Format("2006-01-02 15:04"), name)
+	}
+	return 0
+}
+
+// runExtract 解压归档，指定条目时只解压这些条目及目录下的内容
+func runExtract(args []string) int {
+	output := "."
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-o" {
+			if i+1 == len(args) {
+				fmt.Fprintf(os.Stderr, "Missing directory after -o\n\n%s", usage)
+				return 2
+			}
+			output = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	if len(rest) == 0 {
+		fmt.Fprintf(os.Stderr, "Missing archive\n\n%s", usage)
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	opts := archiver.ExtractOptions{
+		Source:               rest[0],
+		Output:               output,
+		Charset:              archiver.CharsetAuto,
+		Entries:              rest[1:],
+		NormalizeNFC:         true,
+		SanitizeFor:          runtime.GOOS,
+		DetectCaseCollisions: runtime.GOOS == "windows" || runtime.GOOS == "darwin",
+	}
+	extractTuning(cfg, &opts)
+
+	// Ctrl+C 取消解压
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "%s -> %s\n", opts.Source, opts.Output)
+	stats, err := archiver.Extract(ctx, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("%s\n%d files\n", opts.Output, stats.ProcessedFiles)
+	return 0
+}
+
+// runIndex 为 TAR.GZ 归档建立随机访问索引
+func runIndex(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Expected one archive\n\n%s", usage)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stats, err := archiver.BuildGzipIndex(ctx, args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("%s\n%d entries, %d checkpoints, %s\n", stats.Path, stats.Entries, stats.Checkpoints, formatFileSize(stats.Size))
+	return 0
+}
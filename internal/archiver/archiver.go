@@ -14,13 +14,11 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/bodgit/sevenzip"
-	"github.com/dsnet/compress/bzip2"
 	"github.com/klauspost/compress/zstd"
-	"github.com/klauspost/pgzip"
 	"github.com/pierrec/lz4/v4"
-	"github.com/ulikunitz/xz"
 	yekazip "github.com/yeka/zip"
 )
 
@@ -36,6 +34,11 @@ type CompressStats struct {
 	ExcludedFiles   int
 	CurrentFile     string
 	CompressionRate float64
+	Skipped         []SkippedFile // 被排除或过滤的条目及原因
+	// ZstdDict 训练的 Zstd 字典及其效果，未使用字典时为 nil
+	ZstdDict *DictStats
+	// GzipIndex 压缩时写入的 Gzip 索引，未启用时为 nil
+	GzipIndex *GzipIndexStats
 }
 
 // CompressOptions 压缩选项
@@ -43,10 +46,73 @@ type CompressOptions struct {
 	Source     string
 	Output     string
 	Format     string
-	Excludes   []string
-	Password   string // 密码保护（仅支持ZIP）
+	Excludes   []string // 排除模式（gitignore 语法，支持 **、锚定和 ! 否定）
+	Includes   []string // 包含模式，非空时只压缩匹配的文件
+	Password   string   // 密码保护（仅支持ZIP）
+	Level      int      // 压缩级别 1-9，0 使用各格式默认值
 	OnProgress ProgressCallback
 	OnStats    func(stats CompressStats)
+
+	// Sources 多个源路径，非空时代替 Source，每个源在归档中以各自的名称保存
+	Sources []string
+
+	// RespectIgnoreFiles 按目录中的 .gitignore、.ignore、.archiveignore 排除文件（gitignore 语法）
+	RespectIgnoreFiles bool
+
+	// 文件过滤，零值表示不限制
+	MinSize   int64     // 最小文件大小（字节）
+	MaxSize   int64     // 最大文件大小（字节）
+	NewerThan time.Time // 只包含此时间之后修改的文件
+	OlderThan time.Time // 只包含此时间之前修改的文件
+	// FileTypes 只包含指定类型（FileTypeRegular、FileTypeSymlink）的文件
+	// 包含 FileTypeSymlink 时符号链接作为链接条目写入，否则按原有方式写入目标的内容
+	FileTypes []string
+	// SkipBinary 跳过开头包含 NUL 字节的二进制文件
+	SkipBinary bool
+	// Filter 自定义过滤函数，返回 false 时跳过该条目，对目录返回 false 会跳过整个目录
+	Filter func(path string, d fs.DirEntry) bool
+
+	// Reproducible 可复现输出：条目排序，时间戳、属主和权限规范化，相同输入得到相同字节
+	// 不支持 7z 格式和加密 ZIP，与这两者同时使用时 Compress 返回错误
+	Reproducible bool
+	// SourceDateEpoch 可复现模式下的修改时间上限，为空时读取 SOURCE_DATE_EPOCH 环境变量
+	SourceDateEpoch time.Time
+
+	// TarFormat TAR 头格式（USTAR/PAX/GNU），零值表示由 Go 按条目自动选择
+	// 需要兼容老版本 busybox tar 时选择 tar.FormatUSTAR
+	TarFormat tar.Format
+
+	// ArchivePrefix 归档中的根目录名，替换源目录名（可以是 a/b 形式的多级路径）
+	// 为 "." 时条目直接位于归档根部；源为单个文件时替换文件名
+	ArchivePrefix string
+
+	// Workers 并行压缩的协程数，0 使用全部 CPU 核，1 为串行
+	// ZIP 按文件并行压缩，Gzip、XZ、Bzip2 按固定大小分块并行压缩，Zstd 并行编码，并行数不影响输出内容
+	// XZ 的块较大（级别 9 为 192MB），并行数另受 1GB 的内存上限限制
+	Workers int
+
+	// GzipBlockSize TAR.GZ 并行压缩的块大小（字节），0 使用 pgzip 默认的 1MB
+	// 块越大压缩率越接近单线程 gzip，内存占用约为块大小乘以并行数
+	GzipBlockSize int
+	// GzipIndex 压缩 TAR.GZ 时同时建立随机访问索引，写入归档旁的 .gzidx 文件（GzipIndexPath）
+	// 有索引时列出条目和只解压部分条目不必解压整个归档；也可以稍后用 BuildGzipIndex 为已有的归档建立
+	// 建立索引需要在单个协程中解压输出的压缩流（约 100MB/s），多核并行压缩时整体速度受限于这一步
+	GzipIndex bool
+	// ZstdWindowSize Zstd 窗口大小（字节，1KB-512MB 之间的 2 的幂），0 由压缩级别决定（最大 8MB）
+	// 超过 128MB 时 zstd 命令行解压需要 --long=N 或 --memory 参数
+	ZstdWindowSize int
+	// ZstdLong 长距离模式：未指定 ZstdWindowSize 时使用 ZstdLongWindowSize（128MB）的窗口，
+	// 适合重复内容相距很远的大型目录；编码器没有单独的长距离匹配器，效果来自更大的窗口
+	ZstdLong bool
+	// ZstdDict 从待压缩文件中抽样训练 Zstd 字典（ZstdDictEmbed / ZstdDictSidecar），空值不使用
+	// 同时生成不使用字典的压缩流并保留较小的一个，统计中的 ZstdDict 给出两者的大小对比；
+	// 与 ZstdSeekable 同时使用时每帧都使用字典压缩，帧越小字典的收益越明显
+	ZstdDict string
+	// ZstdSeekable TAR.ZST 写成可寻址格式：按 ZstdFrameSize 分成独立的帧，并附带寻址表和条目索引，
+	// 解压时可以并行解码，也可以只读取所选条目；普通 zstd 命令仍能解压，压缩率略低于连续流
+	ZstdSeekable bool
+	// ZstdFrameSize 可寻址格式每帧的未压缩大小（字节，64KB-256MB），0 使用 1MB
+	ZstdFrameSize int
 }
 
 // Get7zCommand 获取系统上可用的 7z 命令
@@ -76,46 +142,71 @@ func Is7zAvailable() bool {
 	return available
 }
 
-// shouldExclude 检查文件是否应该被排除
-func shouldExclude(path string, excludes []string) bool {
-	name := filepath.Base(path)
+// collectFiles 收集所有源路径中需要压缩的文件
+// 多个源时跳过记录中的路径带上源的名称
+func collectFiles(opts CompressOptions) ([]string, int64, []SkippedFile, error) {
+	sources := opts.sourceList()
+	var files []string
+	var totalSize int64
+	var skipped []SkippedFile
 
-	for _, pattern := range excludes {
-		// 检查是否是通配符模式
-		if strings.Contains(pattern, "*") {
-			matched, _ := filepath.Match(pattern, name)
-			if matched {
-				return true
-			}
-		} else {
-			// 精确匹配目录或文件名
-			if name == pattern {
-				return true
-			}
-			// 检查路径中是否包含排除的目录
-			if strings.Contains(path, string(filepath.Separator)+pattern+string(filepath.Separator)) ||
-				strings.HasSuffix(path, string(filepath.Separator)+pattern) {
-				return true
+	for _, source := range sources {
+		sourceFiles, size, sourceSkipped, err := collectSourceFiles(source, opts)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		// 单个文件源本身被跳过时路径为空，同样用源的名称表示
+		for i := range sourceSkipped {
+			if len(sources) > 1 || sourceSkipped[i].Path == "" {
+				sourceSkipped[i].Path = filepath.ToSlash(filepath.Join(filepath.Base(source), sourceSkipped[i].Path))
 			}
 		}
+		files = append(files, sourceFiles...)
+		totalSize += size
+		skipped = append(skipped, sourceSkipped...)
 	}
-	return false
+
+	return files, totalSize, skipped, nil
 }
 
-// collectFiles 收集需要压缩的文件
-func collectFiles(source string, excludes []string) ([]string, int64, int, error) {
+// collectSourceFiles 收集单个源路径中需要压缩的文件
+func collectSourceFiles(source string, opts CompressOptions) ([]string, int64, []SkippedFile, error) {
 	var files []string
 	var totalSize int64
-	excludedCount := 0
+	var skipped []SkippedFile
 
 	sourceInfo, err := os.Stat(source)
 	if err != nil {
-		return nil, 0, 0, err
+		return nil, 0, nil, err
 	}
 
-	// 如果是单个文件
+	// 单个文件按文件名匹配排除和包含模式，再按大小、时间和类型筛选
+	matcher := newPathMatcher(opts.Excludes, opts.Includes)
 	if !sourceInfo.IsDir() {
-		return []string{source}, sourceInfo.Size(), 0, nil
+		name := filepath.Base(source)
+		switch {
+		case matcher.excluded(name, false):
+			return nil, 0, []SkippedFile{{Reason: SkipExcluded}}, nil
+		case !matcher.included(name):
+			return nil, 0, []SkippedFile{{Reason: SkipNotIncluded}}, nil
+		}
+		linkInfo, err := os.Lstat(source)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		reason, err := fileFilterReason(source, fs.FileInfoToDirEntry(linkInfo), opts)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if reason != "" {
+			return nil, 0, []SkippedFile{{Reason: reason}}, nil
+		}
+		return []string{source}, sourceInfo.Size(), nil, nil
+	}
+
+	var ignore *ignoreMatcher
+	if opts.RespectIgnoreFiles {
+		ignore = newIgnoreMatcher(source)
 	}
 
 	err = filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
@@ -123,22 +214,45 @@ func collectFiles(source string, excludes []string) ([]string, int64, int, error
 			return err
 		}
 
-		// 计算相对路径用于排除检查
-		relPath, err := filepath.Rel(filepath.Dir(source), path)
-		if err != nil {
-			relPath = path
+		// 源目录本身不参与匹配
+		if path == source {
+			if ignore != nil {
+				return ignore.loadDir("")
+			}
+			return nil
 		}
 
-		// 检查是否应该排除
-		if shouldExclude(relPath, excludes) || shouldExclude(path, excludes) {
+		// 检查是否应该排除，模式相对源目录匹配
+		relPath := relIgnorePath(source, path)
+		reason := ""
+		switch {
+		case matcher.excluded(relPath, d.IsDir()):
+			reason = SkipExcluded
+		case ignore != nil && ignore.match(relPath, d.IsDir()):
+			reason = SkipIgnored
+		case !d.IsDir() && !matcher.included(relPath):
+			reason = SkipNotIncluded
+		default:
+			reason, err = fileFilterReason(path, d, opts)
+			if err != nil {
+				return err
+			}
+		}
+		if reason != "" {
+			skipped = append(skipped, SkippedFile{Path: relPath, Reason: reason})
 			if d.IsDir() {
-				excludedCount++
 				return filepath.SkipDir
 			}
-			excludedCount++
 			return nil
 		}
 
+		// 进入目录时读取其中的忽略文件
+		if d.IsDir() && ignore != nil {
+			if err := ignore.loadDir(relPath); err != nil {
+				return err
+			}
+		}
+
 		if !d.IsDir() {
 			files = append(files, path)
 			info, err := d.Info()
@@ -149,7 +263,7 @@ func collectFiles(source string, excludes []string) ([]string, int64, int, error
 		return nil
 	})
 
-	return files, totalSize, excludedCount, err
+	return files, totalSize, skipped, err
 }
 
 // Compress 执行压缩操作
@@ -157,19 +271,52 @@ func Compress(ctx context.Context, opts CompressOptions) (*CompressStats, error)
 	stats := &CompressStats{}
 
 	// 检查源文件/目录是否存在
-	_, err := os.Stat(opts.Source)
-	if err != nil {
-		return nil, fmt.Errorf("源路径不存在: %w", err)
+	if err := CheckSources(opts.sourceList()); err != nil {
+		return nil, err
+	}
+	if err := validateLevel(opts.Level); err != nil {
+		return nil, err
+	}
+	if err := validateArchivePrefix(opts.ArchivePrefix); err != nil {
+		return nil, err
+	}
+	if err := ValidateWorkers(opts.Workers); err != nil {
+		return nil, err
+	}
+	if err := validateStreamOptions(opts); err != nil {
+		return nil, err
+	}
+	if err := validateReproducibleOptions(opts); err != nil {
+		return nil, err
+	}
+	if err := validateZstdDictOptions(opts); err != nil {
+		return nil, err
+	}
+	if err := validateZstdSeekableOptions(opts); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.Output), 0755); err != nil {
+		return nil, fmt.Errorf("创建输出目录失败: %w", err)
 	}
 
-	files, totalSize, excludedCount, err := collectFiles(opts.Source, opts.Excludes)
+	files, totalSize, skipped, err := collectFiles(opts)
 	if err != nil {
 		return nil, fmt.Errorf("收集文件失败: %w", err)
 	}
 
 	stats.TotalFiles = len(files)
 	stats.TotalSize = totalSize
-	stats.ExcludedFiles = excludedCount
+	stats.ExcludedFiles = len(skipped)
+	stats.Skipped = skipped
+
+	// 可复现模式：固定条目顺序并解析时间戳上限
+	if opts.Reproducible {
+		sortFilesForArchive(files, opts)
+		opts.SourceDateEpoch, err = resolveSourceDateEpoch(opts)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if stats.TotalFiles == 0 {
 		return nil, fmt.Errorf("没有可压缩的文件")
@@ -220,7 +367,7 @@ func compress7z(ctx context.Context, files []string, opts CompressOptions, stats
 	}
 
 	// 构建 7z 命令参数
-	args := []string{"a", "-mx=9"} // a = add, mx=9 = maximum compression
+	args := []string{"a", fmt.Sprintf("-mx=%d", sevenZipLevel(opts.Level))} // a = add, mx = compression level
 
 	// 如果有密码，添加密码参数
 	if opts.Password != "" {
@@ -229,18 +376,62 @@ func compress7z(ctx context.Context, files []string, opts CompressOptions, stats
 	}
 
 	// 添加输出文件
-	args = append(args, opts.Output)
-
-	// 添加源路径
-	args = append(args, opts.Source)
+	output, err := filepath.Abs(opts.Output)
+	if err != nil {
+		return fmt.Errorf("解析输出路径失败: %w", err)
+	}
+	args = append(args, output)
 
 	// 添加排除规则
-	for _, exclude := range opts.Excludes {
-		args = append(args, "-xr!"+exclude)
+	// 忽略文件、包含模式、文件过滤和 7z 不支持的通配符无法用 -xr! 表达，改为传入已筛选的文件列表
+	excludeArgs, ok := sevenZipExcludeArgs(opts.Excludes)
+	useListFile := !ok || opts.RespectIgnoreFiles || len(opts.Includes) > 0 || hasFileFilters(opts)
+	if keepSymlinks(opts) {
+		args = append(args, "-snl") // 符号链接作为链接保存
+	}
+
+	// ArchivePrefix 为 "." 时从源目录内添加，7z 无法在添加时重命名为其他前缀
+	sources := opts.sourceList()
+	baseDir := filepath.Dir(sources[0])
+	switch {
+	case opts.ArchivePrefix == "" || len(sources) == 1 && opts.ArchivePrefix == filepath.Base(sources[0]):
+	case opts.ArchivePrefix == "." && len(sources) > 1:
+	case opts.ArchivePrefix == ".":
+		if info, err := os.Stat(sources[0]); err == nil && info.IsDir() {
+			baseDir = sources[0]
+			useListFile = true
+		}
+	default:
+		return fmt.Errorf("7z format does not support ArchivePrefix %q (only \".\" is supported)", opts.ArchivePrefix)
+	}
+
+	// 列表文件中的路径相对于同一个目录
+	if useListFile && len(sources) > 1 {
+		for _, source := range sources[1:] {
+			if filepath.Dir(source) != baseDir {
+				return fmt.Errorf("7z format requires all sources to be in the same directory when filters are used")
+			}
+		}
+	}
+
+	// 添加源路径
+	if useListFile {
+		listFile, err := write7zListFile(files, baseDir)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(listFile)
+		args = append(args, "-scsUTF-8", "@"+listFile)
+	} else {
+		args = append(args, sources...)
+		args = append(args, excludeArgs...)
 	}
 
 	// 创建命令
 	command := exec.CommandContext(ctx, cmd7z, args...)
+	if useListFile {
+		command.Dir = baseDir
+	}
 
 	// 更新进度（7z 不能很好地获取进度，所以我们模拟）
 	stats.CurrentFile = "Compressing with 7z..."
@@ -249,9 +440,9 @@ func compress7z(ctx context.Context, files []string, opts CompressOptions, stats
 	}
 
 	// 执行命令
-	output, err := command.CombinedOutput()
+	cmdOutput, err := command.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("7z compression failed: %s\n%s", err, string(output))
+		return fmt.Errorf("7z compression failed: %s\n%s", err, string(cmdOutput))
 	}
 
 	// 更新统计
@@ -260,7 +451,31 @@ func compress7z(ctx context.Context, files []string, opts CompressOptions, stats
 	return nil
 }
 
+// write7zListFile 将文件相对 baseDir 的路径写入临时列表文件，供 7z 的 @listfile 参数使用
+func write7zListFile(files []string, baseDir string) (string, error) {
+	listFile, err := os.CreateTemp("", "simple-archiver-*.lst")
+	if err != nil {
+		return "", fmt.Errorf("failed to create 7z list file: %w", err)
+	}
+	defer listFile.Close()
+
+	w := bufio.NewWriter(listFile)
+	for _, file := range files {
+		relPath, err := filepath.Rel(baseDir, file)
+		if err != nil {
+			relPath = file
+		}
+		fmt.Fprintln(w, relPath)
+	}
+	if err := w.Flush(); err != nil {
+		os.Remove(listFile.Name())
+		return "", fmt.Errorf("failed to write 7z list file: %w", err)
+	}
+	return listFile.Name(), nil
+}
+
 // compressZip 使用 ZIP 格式压缩
+// 条目由多个协程并行压缩（设置密码时同时加密），再按原顺序以 CreateRaw 写入
 func compressZip(ctx context.Context, files []string, opts CompressOptions, stats *CompressStats) error {
 	outFile, err := os.Create(opts.Output)
 	if err != nil {
@@ -268,163 +483,84 @@ func compressZip(ctx context.Context, files []string, opts CompressOptions, stat
 	}
 	defer outFile.Close()
 
-	// 根据是否有密码选择不同的实现
-	if opts.Password != "" {
-		return compressZipWithPassword(ctx, outFile, files, opts, stats)
-	}
-
 	zipWriter := zip.NewWriter(outFile)
 	defer zipWriter.Close()
 
-	baseDir := filepath.Dir(opts.Source)
-
-	for i, file := range files {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		relPath, err := filepath.Rel(baseDir, file)
-		if err != nil {
-			relPath = filepath.Base(file)
-		}
-
+	err = compressZipEntries(ctx, files, opts, func(i int, entry *zipEntry) error {
 		// 更新进度
 		stats.ProcessedFiles = i + 1
-		stats.CurrentFile = relPath
+		stats.CurrentFile = entry.relPath
 		if opts.OnProgress != nil {
-			opts.OnProgress(i+1, len(files), relPath)
+			opts.OnProgress(i+1, len(files), entry.relPath)
 		}
 		if opts.OnStats != nil {
 			opts.OnStats(*stats)
 		}
 
-		// 添加文件到 zip
-		err = addFileToZip(zipWriter, file, relPath)
+		writer, err := zipWriter.CreateRaw(entry.header)
 		if err != nil {
-			return fmt.Errorf("添加文件失败 %s: %w", relPath, err)
+			return fmt.Errorf("添加文件失败 %s: %w", entry.relPath, err)
 		}
-	}
-
-	return nil
-}
-
-// addFileToZip 添加文件到 zip 归档
-func addFileToZip(zw *zip.Writer, filePath, archivePath string) error {
-	file, err := os.Open(filePath)
+		if _, err := entry.data.WriteTo(writer); err != nil {
+			return fmt.Errorf("添加文件失败 %s: %w", entry.relPath, err)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	info, err := file.Stat()
-	if err != nil {
-		return err
-	}
+	return zipWriter.Close()
+}
 
-	header, err := zip.FileInfoHeader(info)
+// compressTarGz 使用 TAR.GZ 格式压缩
+func compressTarGz(ctx context.Context, files []string, opts CompressOptions, stats *CompressStats) error {
+	outFile, err := os.Create(opts.Output)
 	if err != nil {
-		return err
+		return fmt.Errorf("创建输出文件失败: %w", err)
 	}
+	defer outFile.Close()
 
-	header.Name = archivePath
-	header.Method = zip.Deflate
+	// 需要 Gzip 索引时同时解析输出的压缩流，写入要等待单线程的解析，并行压缩会被限制在解压速度
+	var out io.Writer = outFile
+	var indexer *gzipIndexer
+	if opts.GzipIndex {
+		indexer = newGzipIndexer()
+		defer indexer.Finish()
+		out = io.MultiWriter(outFile, indexer)
+	}
 
-	writer, err := zw.CreateHeader(header)
+	gzWriter, err := newGzipWriter(out, opts)
 	if err != nil {
-		return err
+		return fmt.Errorf("创建 Gzip 写入器失败: %w", err)
 	}
+	defer gzWriter.Close()
 
-	_, err = io.Copy(writer, file)
-	return err
-}
-
-// compressZipWithPassword 使用密码保护压缩 ZIP 文件
-func compressZipWithPassword(ctx context.Context, outFile *os.File, files []string, opts CompressOptions, stats *CompressStats) error {
-	zipWriter := yekazip.NewWriter(outFile)
-	defer zipWriter.Close()
-
-	baseDir := filepath.Dir(opts.Source)
-
-	for i, file := range files {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		relPath, err := filepath.Rel(baseDir, file)
-		if err != nil {
-			relPath = filepath.Base(file)
-		}
-
-		// 更新进度
-		stats.ProcessedFiles = i + 1
-		stats.CurrentFile = relPath
-		if opts.OnProgress != nil {
-			opts.OnProgress(i+1, len(files), relPath)
-		}
-		if opts.OnStats != nil {
-			opts.OnStats(*stats)
-		}
-
-		// 添加加密文件到 zip
-		err = addEncryptedFileToZip(zipWriter, file, relPath, opts.Password)
-		if err != nil {
-			return fmt.Errorf("添加加密文件失败 %s: %w", relPath, err)
-		}
+	// 可复现模式：Gzip 头不记录时间和文件名
+	if opts.Reproducible {
+		gzWriter.Header.ModTime = time.Time{}
+		gzWriter.Header.Name = ""
+		gzWriter.Header.OS = 255
 	}
 
-	return nil
-}
-
-// addEncryptedFileToZip 添加加密文件到 zip 归档
-func addEncryptedFileToZip(zw *yekazip.Writer, filePath, archivePath, password string) error {
-	file, err := os.Open(filePath)
-	if err != nil {
+	if err := compressTar(ctx, files, gzWriter, opts, stats); err != nil {
 		return err
 	}
-	defer file.Close()
-
-	info, err := file.Stat()
-	if err != nil {
+	if err := gzWriter.Close(); err != nil {
 		return err
 	}
-
-	header, err := yekazip.FileInfoHeader(info)
-	if err != nil {
-		return err
+	if indexer == nil {
+		return nil
 	}
 
-	header.Name = archivePath
-	header.Method = yekazip.Deflate
-	header.SetPassword(password)
-	header.SetEncryptionMethod(yekazip.AES256Encryption)
-
-	writer, err := zw.CreateHeader(header)
+	index, err := indexer.Finish()
 	if err != nil {
-		return err
+		return fmt.Errorf("生成 Gzip 索引失败: %w", err)
 	}
-
-	_, err = io.Copy(writer, file)
+	stats.GzipIndex, err = writeGzipIndex(index, GzipIndexPath(opts.Output))
 	return err
 }
 
-// compressTarGz 使用 TAR.GZ 格式压缩
-func compressTarGz(ctx context.Context, files []string, opts CompressOptions, stats *CompressStats) error {
-	outFile, err := os.Create(opts.Output)
-	if err != nil {
-		return fmt.Errorf("创建输出文件失败: %w", err)
-	}
-	defer outFile.Close()
-
-	gzWriter := pgzip.NewWriter(outFile)
-	defer gzWriter.Close()
-
-	return compressTar(ctx, files, gzWriter, opts, stats)
-}
-
 // compressTarBz2 使用 TAR.BZ2 格式压缩
 func compressTarBz2(ctx context.Context, files []string, opts CompressOptions, stats *CompressStats) error {
 	outFile, err := os.Create(opts.Output)
@@ -433,13 +569,14 @@ func compressTarBz2(ctx context.Context, files []string, opts CompressOptions, s
 	}
 	defer outFile.Close()
 
-	bz2Writer, err := bzip2.NewWriter(outFile, &bzip2.WriterConfig{Level: bzip2.DefaultCompression})
-	if err != nil {
-		return fmt.Errorf("创建 Bzip2 写入器失败: %w", err)
-	}
+	// 分块并行压缩，生成与 pbzip2 相同的多流文件
+	bz2Writer := newBzip2BlockWriter(outFile, bzip2Level(opts.Level), opts.Workers)
 	defer bz2Writer.Close()
 
-	return compressTar(ctx, files, bz2Writer, opts, stats)
+	if err := compressTar(ctx, files, bz2Writer, opts, stats); err != nil {
+		return err
+	}
+	return bz2Writer.Close()
 }
 
 // compressTarXz 使用 TAR.XZ 格式压缩
@@ -450,13 +587,17 @@ func compressTarXz(ctx context.Context, files []string, opts CompressOptions, st
 	}
 	defer outFile.Close()
 
-	xzWriter, err := xz.NewWriter(outFile)
+	// 分块并行压缩，块头记录大小，解压时可以并行
+	xzWriter, err := newXZBlockWriter(outFile, xzDictCap(opts.Level), opts.Workers)
 	if err != nil {
 		return fmt.Errorf("创建 XZ 写入器失败: %w", err)
 	}
 	defer xzWriter.Close()
 
-	return compressTar(ctx, files, xzWriter, opts, stats)
+	if err := compressTar(ctx, files, xzWriter, opts, stats); err != nil {
+		return err
+	}
+	return xzWriter.Close()
 }
 
 // compressTarZstd 使用 TAR.ZSTD 格式压缩
@@ -467,7 +608,14 @@ func compressTarZstd(ctx context.Context, files []string, opts CompressOptions,
 	}
 	defer outFile.Close()
 
-	zstdWriter, err := zstd.NewWriter(outFile, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	if opts.ZstdDict != "" {
+		return compressTarZstdDict(ctx, files, outFile, opts, stats)
+	}
+	if opts.ZstdSeekable {
+		return compressTarZstdSeekable(ctx, files, outFile, opts, stats)
+	}
+
+	zstdWriter, err := zstd.NewWriter(outFile, zstdEncoderOptions(opts)...)
 	if err != nil {
 		return fmt.Errorf("创建 Zstd 写入器失败: %w", err)
 	}
@@ -485,6 +633,9 @@ func compressTarLz4(ctx context.Context, files []string, opts CompressOptions, s
 	defer outFile.Close()
 
 	lz4Writer := lz4.NewWriter(outFile)
+	if err := lz4Writer.Apply(lz4.CompressionLevelOption(lz4Level(opts.Level))); err != nil {
+		return fmt.Errorf("设置 LZ4 压缩级别失败: %w", err)
+	}
 	defer lz4Writer.Close()
 
 	return compressTar(ctx, files, lz4Writer, opts, stats)
@@ -495,8 +646,6 @@ func compressTar(ctx context.Context, files []string, writer io.Writer, opts Com
 	tarWriter := tar.NewWriter(writer)
 	defer tarWriter.Close()
 
-	baseDir := filepath.Dir(opts.Source)
-
 	for i, file := range files {
 		select {
 		case <-ctx.Done():
@@ -504,10 +653,7 @@ func compressTar(ctx context.Context, files []string, writer io.Writer, opts Com
 		default:
 		}
 
-		relPath, err := filepath.Rel(baseDir, file)
-		if err != nil {
-			relPath = filepath.Base(file)
-		}
+		relPath := archiveEntryName(opts, file)
 
 		// 更新进度
 		stats.ProcessedFiles = i + 1
@@ -520,7 +666,7 @@ func compressTar(ctx context.Context, files []string, writer io.Writer, opts Com
 		}
 
 		// 添加文件到 tar
-		err = addFileToTar(tarWriter, file, relPath)
+		err := addFileToTar(tarWriter, writer, file, relPath, opts)
 		if err != nil {
 			return fmt.Errorf("添加文件失败 %s: %w", relPath, err)
 		}
@@ -530,7 +676,18 @@ func compressTar(ctx context.Context, files []string, writer io.Writer, opts Com
 }
 
 // addFileToTar 添加文件到 tar 归档
-func addFileToTar(tw *tar.Writer, filePath, archivePath string) error {
+// w 为 tar.Writer 的底层写入器，用于写入标准库不支持的稀疏条目
+func addFileToTar(tw *tar.Writer, w io.Writer, filePath, archivePath string, opts CompressOptions) error {
+	if keepSymlinks(opts) {
+		info, target, err := lstatSymlink(filePath)
+		if err != nil {
+			return err
+		}
+		if info != nil {
+			return addSymlinkToTar(tw, info, target, archivePath, opts)
+		}
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return err
@@ -547,7 +704,24 @@ func addFileToTar(tw *tar.Writer, filePath, archivePath string) error {
 		return err
 	}
 
-	header.Name = archivePath
+	header.Name = filepath.ToSlash(archivePath)
+	if opts.Reproducible {
+		normalizeTarHeader(header, opts.SourceDateEpoch)
+	}
+	if err := prepareTarHeader(header, opts.TarFormat); err != nil {
+		return err
+	}
+
+	// 稀疏文件只写入数据区段（仅 PAX 格式支持）
+	if info.Mode().IsRegular() && supportsPAXSparse(opts.TarFormat) {
+		regions, err := sparseDataRegions(file, info.Size())
+		if err != nil {
+			return err
+		}
+		if isSparseLayout(regions, info.Size()) {
+			return writeSparseToTar(tw, w, header, file, regions)
+		}
+	}
 
 	err = tw.WriteHeader(header)
 	if err != nil {
@@ -558,6 +732,23 @@ func addFileToTar(tw *tar.Writer, filePath, archivePath string) error {
 	return err
 }
 
+// addSymlinkToTar 将符号链接作为链接条目写入 tar 归档
+func addSymlinkToTar(tw *tar.Writer, info fs.FileInfo, target, archivePath string, opts CompressOptions) error {
+	header, err := tar.FileInfoHeader(info, target)
+	if err != nil {
+		return err
+	}
+
+	header.Name = filepath.ToSlash(archivePath)
+	if opts.Reproducible {
+		normalizeTarHeader(header, opts.SourceDateEpoch)
+	}
+	if err := prepareTarHeader(header, opts.TarFormat); err != nil {
+		return err
+	}
+	return tw.WriteHeader(header)
+}
+
 // ExtractStats 解压统计信息
 type ExtractStats struct {
 	TotalFiles     int
@@ -565,6 +756,9 @@ type ExtractStats struct {
 	TotalSize      int64
 	ExtractedSize  int64
 	CurrentFile    string
+	Renamed        []RenamedEntry // 因规范化、非法字符或大小写冲突被重命名的条目
+	FlattenedDir   string         // 智能解压时保留原名称的唯一顶层目录
+	Output         string         // 实际的输出目录，与 Output 选项不同时设置（智能解压保留了顶层目录）
 }
 
 // ExtractOptions 解压选项
@@ -574,6 +768,39 @@ type ExtractOptions struct {
 	Password   string // 密码（用于加密归档）
 	OnProgress ProgressCallback
 	OnStats    func(stats ExtractStats)
+
+	// Charset ZIP 中未标记 UTF-8 的文件名编码（auto/gbk/big5/shift-jis/cp437），空值等同 auto
+	Charset string
+
+	// NormalizeNFC 将文件名规范化为 NFC（macOS 生成的归档常用 NFD）
+	NormalizeNFC bool
+	// SanitizeFor 按目标系统（windows/darwin/linux）替换不合法的文件名，空值不处理
+	SanitizeFor string
+	// DetectCaseCollisions 检测仅大小写不同的条目并重命名，适用于大小写不敏感的文件系统
+	DetectCaseCollisions bool
+
+	// Smart 智能解压（同 dtrx）：归档只有一个顶层目录时保留该目录的名称，解压到 Output 的上级目录，避免 project/project 的双层目录
+	// 多个顶层条目或同名目录已存在且非空时仍放在 Output 中；Output 已存在且非空时不生效
+	Smart bool
+	// StripComponents 去掉条目路径的前 N 级（同 tar --strip-components），路径不足 N 级的条目被跳过
+	StripComponents int
+	// Rewrite 依次应用于条目路径的改写规则，在 StripComponents 之后、路径安全检查之前执行
+	Rewrite []RewriteRule
+
+	// Entries 只解压这些条目（归档中的原始路径，选中目录时包括其下所有条目），为空时解压全部
+	// 带索引的可寻址 TAR.ZST 和有 Gzip 索引的 TAR.GZ 直接跳到所选条目，其他格式仍需读取整个归档
+	Entries []string
+
+	// Workers 并行解压的协程数，0 使用全部 CPU 核，1 为串行
+	// ZIP 和 7z 并行写入文件，分块压缩的 XZ 和 Bzip2 并行解码，Zstd 并行解码，Gzip 预读相应数量的块
+	// 并行解码 XZ 时按块大小限制并行数，内存不超过 1GB；块过大或索引不可信时顺序解码
+	Workers int
+
+	// GzipBlockSize TAR.GZ 预读块的大小（字节），0 使用 pgzip 默认值，预读内存约为块大小乘以并行数
+	GzipBlockSize int
+	// ZstdMaxMemory Zstd 解码允许的最大窗口（字节），0 使用默认值（512MB）
+	// 窗口更大的归档会报错而不是占用更多内存，用于限制不可信归档的内存占用
+	ZstdMaxMemory uint64
 }
 
 // DetectArchiveFormat 检测归档格式
@@ -623,31 +850,24 @@ func Extract(ctx context.Context, opts ExtractOptions) (*ExtractStats, error) {
 		return nil, fmt.Errorf("不支持的归档格式")
 	}
 
-	// 创建输出目录
-	if err := os.MkdirAll(opts.Output, 0755); err != nil {
-		return nil, fmt.Errorf("创建输出目录失败: %w", err)
+	if _, err := newEntryRewriter(opts.StripComponents, opts.Rewrite); err != nil {
+		return nil, err
+	}
+	if err := ValidateWorkers(opts.Workers); err != nil {
+		return nil, err
+	}
+	if err := ValidateGzipBlockSize(opts.GzipBlockSize); err != nil {
+		return nil, err
 	}
 
-	// 根据格式选择解压方式
-	switch format {
-	case ".zip":
-		err = extractZip(ctx, opts, stats)
-	case ".7z":
-		err = extract7z(ctx, opts, stats)
-	case ".tar.gz":
-		err = extractTarGz(ctx, opts, stats)
-	case ".tar.bz2":
-		err = extractTarBz2(ctx, opts, stats)
-	case ".tar.xz":
-		err = extractTarXz(ctx, opts, stats)
-	case ".tar.zst":
-		err = extractTarZstd(ctx, opts, stats)
-	case ".tar.lz4":
-		err = extractTarLz4(ctx, opts, stats)
-	case ".tar":
-		err = extractTar(ctx, opts, stats)
-	default:
-		return nil, fmt.Errorf("不支持的归档格式: %s", format)
+	if opts.Smart && isEmptyOrMissing(opts.Output) {
+		err = extractSmart(ctx, format, opts, stats)
+	} else {
+		// 创建输出目录
+		if err := os.MkdirAll(opts.Output, 0755); err != nil {
+			return nil, fmt.Errorf("创建输出目录失败: %w", err)
+		}
+		err = extractFormat(ctx, format, opts, stats)
 	}
 
 	if err != nil {
@@ -657,6 +877,20 @@ func Extract(ctx context.Context, opts ExtractOptions) (*ExtractStats, error) {
 	return stats, nil
 }
 
+// extractFormat 根据格式选择解压方式
+func extractFormat(ctx context.Context, format string, opts ExtractOptions, stats *ExtractStats) error {
+	switch format {
+	case ".zip":
+		return extractZip(ctx, opts, stats)
+	case ".7z":
+		return extract7z(ctx, opts, stats)
+	case ".tar.gz", ".tar.bz2", ".tar.xz", ".tar.zst", ".tar.lz4", ".tar":
+		return extractTarFile(ctx, format, opts, stats)
+	default:
+		return fmt.Errorf("不支持的归档格式: %s", format)
+	}
+}
+
 // extractZip 解压 ZIP 文件
 func extractZip(ctx context.Context, opts ExtractOptions, stats *ExtractStats) error {
 	// 如果有密码，使用支持密码的库
@@ -672,6 +906,20 @@ func extractZip(ctx context.Context, opts ExtractOptions, stats *ExtractStats) e
 
 	stats.TotalFiles = len(reader.File)
 
+	// 转换未标记 UTF-8 的文件名
+	rawNames := make([]string, len(reader.File))
+	flags := make([]uint16, len(reader.File))
+	for i, file := range reader.File {
+		rawNames[i], flags[i] = file.Name, file.Flags
+	}
+	names, err := decodeZipNames(rawNames, flags, opts.Charset)
+	if err != nil {
+		return err
+	}
+	namer := newEntryNamer(opts, stats)
+
+	// 按顺序解析路径并创建目录，文件内容交给工作协程并行写入
+	var tasks []extractTask
 	for i, file := range reader.File {
 		select {
 		case <-ctx.Done():
@@ -679,28 +927,25 @@ func extractZip(ctx context.Context, opts ExtractOptions, stats *ExtractStats) e
 		default:
 		}
 
-		// 更新进度
-		stats.ProcessedFiles = i + 1
-		stats.CurrentFile = file.Name
-		if opts.OnProgress != nil {
-			opts.OnProgress(i+1, len(reader.File), file.Name)
-		}
-		if opts.OnStats != nil {
-			opts.OnStats(*stats)
+		name, ok := namer.resolve(names[i])
+		if !ok {
+			// StripComponents 后没有剩余路径的条目
+			continue
 		}
 
 		// 构建目标路径
-		targetPath := filepath.Join(opts.Output, file.Name)
+		targetPath := filepath.Join(opts.Output, name)
 
 		// 安全检查：防止路径遍历攻击
 		if !strings.HasPrefix(filepath.Clean(targetPath), filepath.Clean(opts.Output)) {
-			return fmt.Errorf("非法的文件路径: %s", file.Name)
+			return fmt.Errorf("非法的文件路径: %s", name)
 		}
 
 		if file.FileInfo().IsDir() {
 			if err := os.MkdirAll(targetPath, file.Mode()); err != nil {
-				return fmt.Errorf("创建目录失败 %s: %w", file.Name, err)
+				return fmt.Errorf("创建目录失败 %s: %w", name, err)
 			}
+			tasks = append(tasks, extractTask{index: i, name: name})
 			continue
 		}
 
@@ -709,15 +954,23 @@ func extractZip(ctx context.Context, opts ExtractOptions, stats *ExtractStats) e
 			return fmt.Errorf("创建父目录失败: %w", err)
 		}
 
-		// 解压文件
-		if err := extractZipFile(file, targetPath); err != nil {
-			return fmt.Errorf("解压文件失败 %s: %w", file.Name, err)
-		}
-
-		stats.ExtractedSize += int64(file.UncompressedSize64)
-	}
-
-	return nil
+		tasks = append(tasks, extractTask{
+			index:  i,
+			name:   name,
+			target: targetPath,
+			mode:   file.Mode(),
+			group:  i,
+			open: func() (io.ReadCloser, error) {
+				rc, err := file.Open()
+				if err != nil {
+					return nil, fmt.Errorf("解压文件失败 %s: %w", name, err)
+				}
+				return rc, nil
+			},
+		})
+	}
+
+	return runExtractTasks(ctx, tasks, len(reader.File), opts, stats)
 }
 
 // extractZipWithPassword 解压密码保护的 ZIP 文件
@@ -730,6 +983,19 @@ func extractZipWithPassword(ctx context.Context, opts ExtractOptions, stats *Ext
 
 	stats.TotalFiles = len(reader.File)
 
+	// 转换未标记 UTF-8 的文件名
+	rawNames := make([]string, len(reader.File))
+	flags := make([]uint16, len(reader.File))
+	for i, file := range reader.File {
+		rawNames[i], flags[i] = file.Name, file.Flags
+	}
+	names, err := decodeZipNames(rawNames, flags, opts.Charset)
+	if err != nil {
+		return err
+	}
+	namer := newEntryNamer(opts, stats)
+
+	var tasks []extractTask
 	for i, file := range reader.File {
 		select {
 		case <-ctx.Done():
@@ -742,28 +1008,25 @@ func extractZipWithPassword(ctx context.Context, opts ExtractOptions, stats *Ext
 			file.SetPassword(opts.Password)
 		}
 
-		// 更新进度
-		stats.ProcessedFiles = i + 1
-		stats.CurrentFile = file.Name
-		if opts.OnProgress != nil {
-			opts.OnProgress(i+1, len(reader.File), file.Name)
-		}
-		if opts.OnStats != nil {
-			opts.OnStats(*stats)
+		name, ok := namer.resolve(names[i])
+		if !ok {
+			// StripComponents 后没有剩余路径的条目
+			continue
 		}
 
 		// 构建目标路径
-		targetPath := filepath.Join(opts.Output, file.Name)
+		targetPath := filepath.Join(opts.Output, name)
 
 		// 安全检查
 		if !strings.HasPrefix(filepath.Clean(targetPath), filepath.Clean(opts.Output)) {
-			return fmt.Errorf("非法的文件路径: %s", file.Name)
+			return fmt.Errorf("非法的文件路径: %s", name)
 		}
 
 		if file.FileInfo().IsDir() {
 			if err := os.MkdirAll(targetPath, file.Mode()); err != nil {
-				return fmt.Errorf("创建目录失败 %s: %w", file.Name, err)
+				return fmt.Errorf("创建目录失败 %s: %w", name, err)
 			}
+			tasks = append(tasks, extractTask{index: i, name: name})
 			continue
 		}
 
@@ -772,50 +1035,27 @@ func extractZipWithPassword(ctx context.Context, opts ExtractOptions, stats *Ext
 			return fmt.Errorf("创建父目录失败: %w", err)
 		}
 
-		// 解压文件
-		rc, err := file.Open()
-		if err != nil {
-			return fmt.Errorf("打开加密文件失败 %s: %w", file.Name, err)
-		}
-
-		outFile, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
-		if err != nil {
-			rc.Close()
-			return fmt.Errorf("创建文件失败 %s: %w", file.Name, err)
-		}
-
-		written, err := io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-		if err != nil {
-			return fmt.Errorf("解压文件失败 %s: %w", file.Name, err)
-		}
-
-		stats.ExtractedSize += written
-	}
-
-	return nil
-}
-
-// extractZipFile 解压单个 ZIP 文件
-func extractZipFile(file *zip.File, targetPath string) error {
-	reader, err := file.Open()
-	if err != nil {
-		return err
-	}
-	defer reader.Close()
-
-	writer, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
-	if err != nil {
-		return err
-	}
-	defer writer.Close()
-
-	_, err = io.Copy(writer, reader)
-	return err
+		tasks = append(tasks, extractTask{
+			index:  i,
+			name:   name,
+			target: targetPath,
+			mode:   file.Mode(),
+			group:  i,
+			open: func() (io.ReadCloser, error) {
+				rc, err := file.Open()
+				if err != nil {
+					return nil, fmt.Errorf("打开加密文件失败 %s: %w", name, err)
+				}
+				return rc, nil
+			},
+		})
+	}
+
+	return runExtractTasks(ctx, tasks, len(reader.File), opts, stats)
 }
 
 // extract7z 解压 7z 文件
+// 同一个固实块中的文件只能顺序解码，按块分组后不同块之间并行，非固实归档的每个文件都可以并行
 func extract7z(ctx context.Context, opts ExtractOptions, stats *ExtractStats) error {
 	var reader *sevenzip.ReadCloser
 	var err error
@@ -832,7 +1072,9 @@ func extract7z(ctx context.Context, opts ExtractOptions, stats *ExtractStats) er
 	defer reader.Close()
 
 	stats.TotalFiles = len(reader.File)
+	namer := newEntryNamer(opts, stats)
 
+	var tasks []extractTask
 	for i, file := range reader.File {
 		select {
 		case <-ctx.Done():
@@ -840,28 +1082,25 @@ func extract7z(ctx context.Context, opts ExtractOptions, stats *ExtractStats) er
 		default:
 		}
 
-		// 更新进度
-		stats.ProcessedFiles = i + 1
-		stats.CurrentFile = file.Name
-		if opts.OnProgress != nil {
-			opts.OnProgress(i+1, len(reader.File), file.Name)
-		}
-		if opts.OnStats != nil {
-			opts.OnStats(*stats)
+		name, ok := namer.resolve(file.Name)
+		if !ok {
+			// StripComponents 后没有剩余路径的条目
+			continue
 		}
 
 		// 构建目标路径
-		targetPath := filepath.Join(opts.Output, file.Name)
+		targetPath := filepath.Join(opts.Output, name)
 
 		// 安全检查
 		if !strings.HasPrefix(filepath.Clean(targetPath), filepath.Clean(opts.Output)) {
-			return fmt.Errorf("非法的文件路径: %s", file.Name)
+			return fmt.Errorf("非法的文件路径: %s", name)
 		}
 
 		if file.FileInfo().IsDir() {
 			if err := os.MkdirAll(targetPath, 0755); err != nil {
-				return fmt.Errorf("创建目录失败 %s: %w", file.Name, err)
+				return fmt.Errorf("创建目录失败 %s: %w", name, err)
 			}
+			tasks = append(tasks, extractTask{index: i, name: name})
 			continue
 		}
 
@@ -870,126 +1109,118 @@ func extract7z(ctx context.Context, opts ExtractOptions, stats *ExtractStats) er
 			return fmt.Errorf("创建父目录失败: %w", err)
 		}
 
-		// 解压文件
-		rc, err := file.Open()
-		if err != nil {
-			return fmt.Errorf("打开 7z 文件条目失败 %s: %w", file.Name, err)
+		// 空文件不属于任何块，单独成组
+		group := file.Stream
+		if file.UncompressedSize == 0 {
+			group = -1 - i
 		}
 
-		outFile, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-		if err != nil {
-			rc.Close()
-			return fmt.Errorf("创建文件失败 %s: %w", file.Name, err)
-		}
-
-		written, err := io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-		if err != nil {
-			return fmt.Errorf("写入文件失败 %s: %w", file.Name, err)
-		}
-
-		stats.ExtractedSize += written
-	}
-
-	return nil
+		tasks = append(tasks, extractTask{
+			index:  i,
+			name:   name,
+			target: targetPath,
+			mode:   0644,
+			group:  group,
+			open: func() (io.ReadCloser, error) {
+				rc, err := file.Open()
+				if err != nil {
+					return nil, fmt.Errorf("打开 7z 文件条目失败 %s: %w", name, err)
+				}
+				return rc, nil
+			},
+		})
+	}
+
+	return runExtractTasks(ctx, tasks, len(reader.File), opts, stats)
 }
 
-// extractTarGz 解压 TAR.GZ 文件
-func extractTarGz(ctx context.Context, opts ExtractOptions, stats *ExtractStats) error {
+// extractTarFile 解压 TAR 及各种压缩的 TAR 文件
+func extractTarFile(ctx context.Context, format string, opts ExtractOptions, stats *ExtractStats) error {
 	file, err := os.Open(opts.Source)
 	if err != nil {
 		return fmt.Errorf("打开文件失败: %w", err)
 	}
 	defer file.Close()
 
-	gzReader, err := pgzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("创建 Gzip 读取器失败: %w", err)
-	}
-	defer gzReader.Close()
-
-	return extractTarReader(ctx, gzReader, opts, stats)
-}
-
-// extractTarBz2 解压 TAR.BZ2 文件
-func extractTarBz2(ctx context.Context, opts ExtractOptions, stats *ExtractStats) error {
-	file, err := os.Open(opts.Source)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
-	}
-	defer file.Close()
-
-	bz2Reader, err := bzip2.NewReader(file, nil)
-	if err != nil {
-		return fmt.Errorf("创建 Bzip2 读取器失败: %w", err)
-	}
-	defer bz2Reader.Close()
-
-	return extractTarReader(ctx, bz2Reader, opts, stats)
-}
-
-// extractTarXz 解压 TAR.XZ 文件
-func extractTarXz(ctx context.Context, opts ExtractOptions, stats *ExtractStats) error {
-	file, err := os.Open(opts.Source)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
-	}
-	defer file.Close()
-
-	xzReader, err := xz.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("创建 XZ 读取器失败: %w", err)
-	}
-
-	return extractTarReader(ctx, xzReader, opts, stats)
-}
-
-// extractTarZstd 解压 TAR.ZSTD 文件
-func extractTarZstd(ctx context.Context, opts ExtractOptions, stats *ExtractStats) error {
-	file, err := os.Open(opts.Source)
+	reader, err := openTarStream(file, format, opts)
 	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
+		return err
 	}
-	defer file.Close()
+	defer reader.Close()
 
-	zstdReader, err := zstd.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("创建 Zstd 读取器失败: %w", err)
+	err = extractTarReader(ctx, reader, opts, stats)
+	if format == ".tar.zst" {
+		err = zstdReadError(err, opts)
 	}
-	defer zstdReader.Close()
-
-	return extractTarReader(ctx, zstdReader, opts, stats)
+	return err
 }
 
-// extractTarLz4 解压 TAR.LZ4 文件
-func extractTarLz4(ctx context.Context, opts ExtractOptions, stats *ExtractStats) error {
-	file, err := os.Open(opts.Source)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
-	}
-	defer file.Close()
-
-	lz4Reader := lz4.NewReader(file)
+// openTarStream 打开解压后的 TAR 流
+// 指定了 Entries 时，可寻址 TAR.ZST 和带索引的 TAR.GZ 只包含所选条目，其他情况返回完整的流
+func openTarStream(file *os.File, format string, opts ExtractOptions) (io.ReadCloser, error) {
+	switch format {
+	case ".tar.gz":
+		// 有 Gzip 索引时从所选条目之前最近的检查点开始解码
+		if len(opts.Entries) > 0 {
+			index, err := loadGzipIndex(file, opts.Source)
+			if err != nil {
+				return nil, err
+			}
+			if index != nil {
+				seekable := &gzipSeekable{file: file, index: index}
+				return io.NopCloser(index.Tar.selectedTarStream(opts.Entries, seekable.section)), nil
+			}
+		}
+		gzReader, err := newGzipReader(file, opts)
+		if err != nil {
+			return nil, fmt.Errorf("创建 Gzip 读取器失败: %w", err)
+		}
+		return gzReader, nil
+	case ".tar.bz2":
+		bz2Reader, err := newBzip2Reader(file, opts.Workers)
+		if err != nil {
+			return nil, fmt.Errorf("创建 Bzip2 读取器失败: %w", err)
+		}
+		return bz2Reader, nil
+	case ".tar.xz":
+		xzReader, err := newXZReader(file, opts.Workers)
+		if err != nil {
+			return nil, fmt.Errorf("创建 XZ 读取器失败: %w", err)
+		}
+		return xzReader, nil
+	case ".tar.zst":
+		// 可寻址格式的各帧独立，可以并行解码或直接跳到所选条目
+		seekable, err := openZstdSeekable(file, opts)
+		if err != nil {
+			return nil, err
+		}
+		if seekable != nil {
+			return seekable.tarStream(opts)
+		}
 
-	return extractTarReader(ctx, lz4Reader, opts, stats)
-}
+		// 使用字典压缩的归档需要先读取嵌入或旁路的字典
+		decoderOptions, err := zstdDictDecoderOptions(file, opts)
+		if err != nil {
+			return nil, err
+		}
 
-// extractTar 解压 TAR 文件
-func extractTar(ctx context.Context, opts ExtractOptions, stats *ExtractStats) error {
-	file, err := os.Open(opts.Source)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
+		zstdReader, err := zstd.NewReader(file, decoderOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("创建 Zstd 读取器失败: %w", err)
+		}
+		return zstdReader.IOReadCloser(), nil
+	case ".tar.lz4":
+		return io.NopCloser(lz4.NewReader(file)), nil
+	default:
+		return io.NopCloser(bufio.NewReader(file)), nil
 	}
-	defer file.Close()
-
-	return extractTarReader(ctx, bufio.NewReader(file), opts, stats)
 }
 
 // extractTarReader TAR 解压通用函数
 func extractTarReader(ctx context.Context, reader io.Reader, opts ExtractOptions, stats *ExtractStats) error {
 	tarReader := tar.NewReader(reader)
 	fileCount := 0
+	namer := newEntryNamer(opts, stats)
 
 	for {
 		select {
@@ -1006,31 +1237,39 @@ func extractTarReader(ctx context.Context, reader io.Reader, opts ExtractOptions
 			return fmt.Errorf("读取 TAR 头失败: %w", err)
 		}
 
+		if !namer.selector.match(header.Name) {
+			continue
+		}
 		fileCount++
+		name, ok := namer.resolve(header.Name)
+		if !ok {
+			// StripComponents 后没有剩余路径的条目
+			continue
+		}
 		stats.ProcessedFiles = fileCount
-		stats.CurrentFile = header.Name
+		stats.CurrentFile = name
 		if opts.OnProgress != nil {
-			opts.OnProgress(fileCount, 0, header.Name) // TAR 不知道总文件数
+			opts.OnProgress(fileCount, 0, name) // TAR 不知道总文件数
 		}
 		if opts.OnStats != nil {
 			opts.OnStats(*stats)
 		}
 
 		// 构建目标路径
-		targetPath := filepath.Join(opts.Output, header.Name)
+		targetPath := filepath.Join(opts.Output, name)
 
 		// 安全检查：防止路径遍历攻击
 		if !strings.HasPrefix(filepath.Clean(targetPath), filepath.Clean(opts.Output)) {
-			return fmt.Errorf("非法的文件路径: %s", header.Name)
+			return fmt.Errorf("非法的文件路径: %s", name)
 		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
 			if err := os.MkdirAll(targetPath, os.FileMode(header.Mode)); err != nil {
-				return fmt.Errorf("创建目录失败 %s: %w", header.Name, err)
+				return fmt.Errorf("创建目录失败 %s: %w", name, err)
 			}
 
-		case tar.TypeReg:
+		case tar.TypeReg, tar.TypeGNUSparse:
 			// 确保父目录存在
 			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
 				return fmt.Errorf("创建父目录失败: %w", err)
@@ -1039,13 +1278,19 @@ func extractTarReader(ctx context.Context, reader io.Reader, opts ExtractOptions
 			// 写入文件
 			outFile, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode))
 			if err != nil {
-				return fmt.Errorf("创建文件失败 %s: %w", header.Name, err)
+				return fmt.Errorf("创建文件失败 %s: %w", name, err)
 			}
 
-			written, err := io.Copy(outFile, tarReader)
+			var written int64
+			if isSparseTarHeader(header) {
+				// 稀疏文件通过 Seek 重建空洞
+				written, err = copySparse(outFile, tarReader, header.Size)
+			} else {
+				written, err = io.Copy(outFile, tarReader)
+			}
 			outFile.Close()
 			if err != nil {
-				return fmt.Errorf("写入文件失败 %s: %w", header.Name, err)
+				return fmt.Errorf("写入文件失败 %s: %w", name, err)
 			}
 
 			stats.ExtractedSize += written
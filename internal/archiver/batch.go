@@ -0,0 +1,160 @@
+package archiver
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// 批量任务状态
+const (
+	JobPending  = "pending"  // 等待执行
+	JobRunning  = "running"  // 正在执行
+	JobDone     = "done"     // 已完成
+	JobFailed   = "failed"   // 执行失败
+	JobCanceled = "canceled" // 已取消
+)
+
+// maxBatchWorkers 批量任务的最大并发数
+const maxBatchWorkers = 64
+
+// BatchJob 批量任务中的一项，Compress 和 Extract 只设置一个
+type BatchJob struct {
+	Name     string // 显示名称
+	Compress *CompressOptions
+	Extract  *ExtractOptions
+}
+
+// JobUpdate 单个任务的状态或进度变化
+type JobUpdate struct {
+	Index       int    // 任务在 jobs 中的下标
+	Status      string // 见 JobPending 等常量
+	Processed   int    // 已处理的文件数
+	Total       int    // 文件总数，未知时为 0（TAR 解压）
+	CurrentFile string
+	Err         error // 任务失败时的错误
+}
+
+// BatchResult 单个任务的结果
+type BatchResult struct {
+	Status        string
+	Err           error
+	CompressStats *CompressStats
+	ExtractStats  *ExtractStats
+}
+
+// DefaultBatchWorkers 默认的并发数：CPU 核数，最多 4 个
+func DefaultBatchWorkers() int {
+	return min(runtime.NumCPU(), 4)
+}
+
+// ValidateBatchWorkers 检查并发数，0 表示使用默认值
+func ValidateBatchWorkers(workers int) error {
+	if workers < 0 || workers > maxBatchWorkers {
+		return fmt.Errorf("并发数必须在 1-%d 之间，0 表示默认: %d", maxBatchWorkers, workers)
+	}
+	return nil
+}
+
+// RunBatch 用最多 workers 个协程依次执行任务，workers 为 0 时使用 DefaultBatchWorkers
+// 单个任务失败不影响其他任务；ctx 取消后尚未开始的任务标记为 JobCanceled
+// onUpdate 在任务开始、进度变化和结束时调用，同一时刻只有一个调用
+func RunBatch(ctx context.Context, jobs []BatchJob, workers int, onUpdate func(JobUpdate)) []BatchResult {
+	results := make([]BatchResult, len(jobs))
+	for i := range results {
+		results[i].Status = JobPending
+	}
+	if len(jobs) == 0 {
+		return results
+	}
+
+	if workers <= 0 {
+		workers = DefaultBatchWorkers()
+	}
+	workers = min(workers, len(jobs))
+
+	var mu sync.Mutex
+	update := func(u JobUpdate) {
+		if onUpdate == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		onUpdate(u)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = runJob(ctx, i, jobs[i], update)
+			}
+		}()
+	}
+
+	for i := range jobs {
+		if ctx.Err() != nil {
+			results[i] = BatchResult{Status: JobCanceled, Err: ctx.Err()}
+			update(JobUpdate{Index: i, Status: JobCanceled, Err: ctx.Err()})
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// runJob 执行单个任务，进度通过 update 报告
+func runJob(ctx context.Context, index int, job BatchJob, update func(JobUpdate)) BatchResult {
+	if ctx.Err() != nil {
+		update(JobUpdate{Index: index, Status: JobCanceled, Err: ctx.Err()})
+		return BatchResult{Status: JobCanceled, Err: ctx.Err()}
+	}
+	update(JobUpdate{Index: index, Status: JobRunning})
+
+	var result BatchResult
+	var err error
+	switch {
+	case job.Compress != nil:
+		opts := *job.Compress
+		onStats := opts.OnStats
+		opts.OnStats = func(stats CompressStats) {
+			if onStats != nil {
+				onStats(stats)
+			}
+			update(JobUpdate{Index: index, Status: JobRunning, Processed: stats.ProcessedFiles, Total: stats.TotalFiles, CurrentFile: stats.CurrentFile})
+		}
+		result.CompressStats, err = Compress(ctx, opts)
+	case job.Extract != nil:
+		opts := *job.Extract
+		onStats := opts.OnStats
+		opts.OnStats = func(stats ExtractStats) {
+			if onStats != nil {
+				onStats(stats)
+			}
+			update(JobUpdate{Index: index, Status: JobRunning, Processed: stats.ProcessedFiles, Total: stats.TotalFiles, CurrentFile: stats.CurrentFile})
+		}
+		result.ExtractStats, err = Extract(ctx, opts)
+	default:
+		err = fmt.Errorf("任务 %q 没有设置压缩或解压选项", job.Name)
+	}
+
+	switch {
+	case err == nil:
+		result.Status = JobDone
+	case ctx.Err() != nil:
+		// 取消时 7z 等外部命令返回的错误不一定包装了 ctx.Err()
+		result.Status = JobCanceled
+	default:
+		result.Status = JobFailed
+	}
+	result.Err = err
+	update(JobUpdate{Index: index, Status: result.Status, Err: err})
+	return result
+}
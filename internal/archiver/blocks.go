@@ -0,0 +1,200 @@
+package archiver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// blockWriter 将写入的数据切成 blockSize 大小的块，由多个协程并行编码，
+// 再按顺序交给 emit；块的划分只取决于 blockSize，输出与并行数无关
+type blockWriter[T any] struct {
+	blockSize int
+	encode    func(block []byte) (T, error)
+	emit      func(T) error
+
+	buf     []byte
+	sem     chan struct{}        // 限制同时编码的块数
+	pending chan chan blockOf[T] // 按顺序等待写出的块，容量限制内存占用
+	done    chan struct{}
+	mu      sync.Mutex
+	err     error
+	closed  bool
+}
+
+// blockOf 一个块的编码结果
+type blockOf[T any] struct {
+	value T
+	err   error
+}
+
+// newBlockWriter 创建并行块编码器，workers 为 0 时使用全部 CPU 核
+func newBlockWriter[T any](blockSize, workers int, encode func([]byte) (T, error), emit func(T) error) *blockWriter[T] {
+	workers = resolveWorkers(workers)
+	w := &blockWriter[T]{
+		blockSize: blockSize,
+		encode:    encode,
+		emit:      emit,
+		sem:       make(chan struct{}, workers),
+		pending:   make(chan chan blockOf[T], workers),
+		done:      make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// run 按顺序写出编码好的块，出错后丢弃其余的块
+func (w *blockWriter[T]) run() {
+	defer close(w.done)
+	for result := range w.pending {
+		r := <-result
+		if w.failed() != nil {
+			continue
+		}
+		if r.err == nil {
+			r.err = w.emit(r.value)
+		}
+		if r.err != nil {
+			w.fail(r.err)
+		}
+	}
+}
+
+func (w *blockWriter[T]) failed() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *blockWriter[T]) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// submit 开始编码一个块，等待写出的块已满时阻塞
+func (w *blockWriter[T]) submit(block []byte) {
+	result := make(chan blockOf[T], 1)
+	w.pending <- result
+	w.sem <- struct{}{}
+	go func() {
+		value, err := w.encode(block)
+		<-w.sem
+		result <- blockOf[T]{value, err}
+	}()
+}
+
+func (w *blockWriter[T]) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("写入已关闭的压缩流")
+	}
+	if err := w.failed(); err != nil {
+		return 0, err
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		if w.buf == nil {
+			w.buf = make([]byte, 0, w.blockSize)
+		}
+		m := min(len(p), w.blockSize-len(w.buf))
+		w.buf = append(w.buf, p[:m]...)
+		p = p[m:]
+		if len(w.buf) == w.blockSize {
+			w.submit(w.buf)
+			w.buf = nil
+		}
+	}
+	return n, nil
+}
+
+// Close 编码剩余数据并等待所有块写出，不关闭底层写入器
+func (w *blockWriter[T]) Close() error {
+	if w.closed {
+		return w.failed()
+	}
+	w.closed = true
+	if len(w.buf) > 0 {
+		w.submit(w.buf)
+		w.buf = nil
+	}
+	close(w.pending)
+	<-w.done
+	return w.failed()
+}
+
+// orderedReader 用多个协程解码 count 个独立的段，按顺序输出解码结果
+// 同时保存在内存中的段不超过并行数的两倍；解码第 i 段出错时，
+// 如果 fallback 不为 nil，则从第 i 段起改用 fallback 返回的顺序读取器
+type orderedReader struct {
+	pr     *io.PipeReader
+	cancel context.CancelFunc
+}
+
+// newOrderedReader 创建顺序输出的并行解码器，workers 为 0 时使用全部 CPU 核
+func newOrderedReader(count, workers int, decode func(i int) ([]byte, error), fallback func(i int) (io.Reader, error)) *orderedReader {
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	workers = resolveWorkers(workers)
+
+	results := make(chan chan blockOf[[]byte], workers*2)
+	go func() {
+		defer close(results)
+		sem := make(chan struct{}, workers)
+		for i := range count {
+			result := make(chan blockOf[[]byte], 1)
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+			sem <- struct{}{}
+			go func() {
+				data, err := decode(i)
+				<-sem
+				result <- blockOf[[]byte]{data, err}
+			}()
+		}
+	}()
+
+	go func() {
+		i := 0
+		for result := range results {
+			r := <-result
+			if r.err != nil && fallback != nil {
+				cancel()
+				rest, err := fallback(i)
+				if err == nil {
+					_, err = io.Copy(pw, rest)
+				}
+				pw.CloseWithError(err)
+				return
+			}
+			if r.err == nil && len(r.value) > 0 {
+				_, r.err = pw.Write(r.value)
+			}
+			if r.err != nil {
+				cancel()
+				pw.CloseWithError(r.err)
+				return
+			}
+			i++
+		}
+		pw.Close()
+	}()
+
+	return &orderedReader{pr: pr, cancel: cancel}
+}
+
+func (r *orderedReader) Read(p []byte) (int, error) {
+	return r.pr.Read(p)
+}
+
+// Close 停止解码并释放协程
+func (r *orderedReader) Close() error {
+	r.cancel()
+	return r.pr.Close()
+}
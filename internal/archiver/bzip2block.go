@@ -0,0 +1,121 @@
+package archiver
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/dsnet/compress/bzip2"
+)
+
+// 与 pbzip2 相同，并行压缩时每块数据编码为一个独立的 Bzip2 流，多个流首尾相连
+// 解压时按流头的位置切分，每段单独解码
+const (
+	bzip2LevelBlock  = 100_000 // 每级压缩对应的块大小
+	bzip2MaxSegment  = 2 << 20 // 超过此大小的流不是分块压缩生成的，改为顺序解码
+	bzip2ScanBufSize = 1 << 20
+)
+
+// bzip2BlockMagic 流头 "BZh1"~"BZh9" 之后紧跟块魔数 0x314159265359
+var bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+
+// newBzip2BlockWriter 创建并行 Bzip2 写入器
+func newBzip2BlockWriter(w io.Writer, level, workers int) *blockWriter[[]byte] {
+	return newBlockWriter(level*bzip2LevelBlock, workers, func(block []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		bw, err := bzip2.NewWriter(&buf, &bzip2.WriterConfig{Level: level})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := bw.Write(block); err != nil {
+			return nil, err
+		}
+		if err := bw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}, func(data []byte) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// scanBzip2Streams 查找各个流的起始位置
+// 只有一个流或流过大（普通 bzip2 生成的文件）时返回 nil
+func scanBzip2Streams(file *os.File) ([]int64, error) {
+	r := bufio.NewReaderSize(io.NewSectionReader(file, 0, 1<<62), bzip2ScanBufSize)
+	var offsets []int64
+	var window []byte
+	var base int64 // window[0] 在文件中的偏移
+	for {
+		chunk := make([]byte, bzip2ScanBufSize)
+		n, err := io.ReadFull(r, chunk)
+		window = append(window, chunk[:n]...)
+
+		for i := 0; i+10 <= len(window); i++ {
+			if window[i] == 'B' && window[i+1] == 'Z' && window[i+2] == 'h' &&
+				window[i+3] >= '1' && window[i+3] <= '9' && bytes.Equal(window[i+4:i+10], bzip2BlockMagic) {
+				offset := base + int64(i)
+				if len(offsets) > 0 && offset-offsets[len(offsets)-1] > bzip2MaxSegment {
+					return nil, nil
+				}
+				offsets = append(offsets, offset)
+			}
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// 保留末尾 9 个字节，避免魔数跨越两次读取
+		keep := min(len(window), 9)
+		base += int64(len(window) - keep)
+		window = append([]byte{}, window[len(window)-keep:]...)
+		if len(offsets) == 0 && base > 0 || len(offsets) > 0 && base-offsets[len(offsets)-1] > bzip2MaxSegment {
+			return nil, nil
+		}
+	}
+
+	if len(offsets) < 2 || offsets[0] != 0 {
+		return nil, nil
+	}
+	return offsets, nil
+}
+
+// newBzip2Reader 打开 Bzip2 文件：多个流首尾相连时并行解码，否则顺序解码
+// 压缩数据中偶然出现的魔数会使切分出的段解码失败，此时从该段起改为顺序解码
+func newBzip2Reader(file *os.File, workers int) (io.ReadCloser, error) {
+	var offsets []int64
+	if resolveWorkers(workers) > 1 {
+		var err error
+		if offsets, err = scanBzip2Streams(file); err != nil {
+			return nil, err
+		}
+	}
+	if offsets == nil {
+		return bzip2.NewReader(file, nil)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offsets = append(offsets, info.Size())
+
+	return newOrderedReader(len(offsets)-1, workers, func(i int) ([]byte, error) {
+		segment := make([]byte, offsets[i+1]-offsets[i])
+		if _, err := file.ReadAt(segment, offsets[i]); err != nil {
+			return nil, err
+		}
+		br, err := bzip2.NewReader(bytes.NewReader(segment), nil)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(br)
+	}, func(i int) (io.Reader, error) {
+		return bzip2.NewReader(io.NewSectionReader(file, offsets[i], info.Size()-offsets[i]), nil)
+	}), nil
+}
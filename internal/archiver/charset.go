@@ -0,0 +1,267 @@
+package archiver
+
+import (
+	"archive/zip"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// ZIP 文件名编码
+const (
+	CharsetAuto     = "auto"
+	CharsetUTF8     = "utf-8"
+	CharsetGBK      = "gbk"
+	CharsetBig5     = "big5"
+	CharsetShiftJIS = "shift-jis"
+	CharsetCP437    = "cp437"
+)
+
+// zipFlagUTF8 ZIP 通用标志位第 11 位：文件名使用 UTF-8 编码
+const zipFlagUTF8 = 0x800
+
+// ZipCharsets 解压时可选的文件名编码
+var ZipCharsets = []string{CharsetAuto, CharsetUTF8, CharsetGBK, CharsetBig5, CharsetShiftJIS, CharsetCP437}
+
+// detectCandidates 自动检测时依次尝试的编码，得分相同时靠前的优先
+var detectCandidates = []string{CharsetGBK, CharsetShiftJIS, CharsetBig5}
+
+// charsetEncoding 返回编码名称对应的解码器
+func charsetEncoding(charset string) (encoding.Encoding, error) {
+	switch charset {
+	case CharsetUTF8:
+		return encoding.Nop, nil
+	case CharsetGBK:
+		// GB18030 是 GBK 的超集
+		return simplifiedchinese.GB18030, nil
+	case CharsetBig5:
+		return traditionalchinese.Big5, nil
+	case CharsetShiftJIS:
+		return japanese.ShiftJIS, nil
+	case CharsetCP437:
+		return charmap.CodePage437, nil
+	default:
+		return nil, fmt.Errorf("不支持的文件名编码: %s", charset)
+	}
+}
+
+// isLegacyZipName 判断文件名是否可能使用了非 UTF-8 编码
+// 设置了 UTF-8 标志或只含 ASCII 的文件名无需转换
+func isLegacyZipName(name string, flags uint16) bool {
+	return flags&zipFlagUTF8 == 0 && !isASCII(name)
+}
+
+// decodeLegacyName 使用指定编码解码文件名，出现无法解码的字节时返回 false
+func decodeLegacyName(raw string, enc encoding.Encoding) (string, bool) {
+	if enc == encoding.Nop {
+		return raw, utf8.ValidString(raw)
+	}
+	decoded, err := enc.NewDecoder().String(raw)
+	if err != nil {
+		return raw, false
+	}
+	for _, r := range decoded {
+		if r == utf8.RuneError || unicode.IsControl(r) || unicode.Is(unicode.Co, r) {
+			return decoded, false
+		}
+	}
+	return decoded, true
+}
+
+// charsetScore 按原始字节评估文件名属于某种编码的可能性
+// 常用字区间加分，半角片假名、扩展区等文件名中少见的区间减分
+func charsetScore(charset, raw string) int {
+	score := 0
+	for i := 0; i < len(raw); i++ {
+		b := raw[i]
+		if b < utf8.RuneSelf {
+			continue
+		}
+
+		switch charset {
+		case CharsetShiftJIS:
+			if b >= 0xA1 && b <= 0xDF { // 半角片假名
+				score--
+				continue
+			}
+			switch {
+			case b == 0x82 || b == 0x83: // 平假名、片假名
+				score += 2
+			case b >= 0x88 && b <= 0x98: // 第一水准汉字
+				score += 2
+			case b == 0x81: // 全角符号
+				score++
+			}
+		case CharsetGBK:
+			var trail byte
+			if i+1 < len(raw) {
+				trail = raw[i+1]
+			}
+			switch {
+			case b >= 0xB0 && b <= 0xD7 && trail >= 0xA1: // GB2312 一级汉字
+				score += 2
+			case b >= 0xA1 && b <= 0xF7 && trail >= 0xA1: // GB2312 符号、二级汉字
+			default: // GBK 扩展区
+				score--
+			}
+		case CharsetBig5:
+			switch {
+			case b >= 0xA4 && b <= 0xC6: // 常用字
+				score += 2
+			case b >= 0xC9 && b <= 0xF9: // 次常用字
+			default:
+				score--
+			}
+		}
+		i++ // 跳过双字节字符的尾字节
+	}
+	return score
+}
+
+// detectCharset 根据文件名推测编码
+func detectCharset(rawNames []string) string {
+	if len(rawNames) == 0 {
+		return CharsetUTF8
+	}
+
+	// 很多工具写入 UTF-8 但不设置标志位
+	allUTF8 := true
+	for _, name := range rawNames {
+		if !utf8.ValidString(name) {
+			allUTF8 = false
+			break
+		}
+	}
+	if allUTF8 {
+		return CharsetUTF8
+	}
+
+	best, bestScore := CharsetCP437, 0
+	for _, charset := range detectCandidates {
+		enc, _ := charsetEncoding(charset)
+		score, valid := 0, true
+		for _, name := range rawNames {
+			if _, ok := decodeLegacyName(name, enc); !ok {
+				valid = false
+				break
+			}
+			score += charsetScore(charset, name)
+		}
+		if valid && (best == CharsetCP437 || score > bestScore) {
+			best, bestScore = charset, score
+		}
+	}
+	return best
+}
+
+// resolveZipCharset 确定实际使用的编码，auto 或空值时自动检测
+func resolveZipCharset(charset string, rawNames []string) (string, error) {
+	if charset == "" || charset == CharsetAuto {
+		return detectCharset(rawNames), nil
+	}
+	if _, err := charsetEncoding(charset); err != nil {
+		return "", err
+	}
+	return charset, nil
+}
+
+// decodeZipNames 将 ZIP 条目名称转换为 UTF-8
+func decodeZipNames(names []string, flags []uint16, charset string) ([]string, error) {
+	var legacy []string
+	for i, name := range names {
+		if isLegacyZipName(name, flags[i]) {
+			legacy = append(legacy, name)
+		}
+	}
+
+	decoded := make([]string, len(names))
+	copy(decoded, names)
+	if len(legacy) == 0 {
+		return decoded, nil
+	}
+
+	resolved, err := resolveZipCharset(charset, legacy)
+	if err != nil {
+		return nil, err
+	}
+	enc, _ := charsetEncoding(resolved)
+
+	for i, name := range names {
+		if isLegacyZipName(name, flags[i]) {
+			decoded[i], _ = decodeLegacyName(name, enc)
+		}
+	}
+	return decoded, nil
+}
+
+// readZipNames 读取 ZIP 中所有条目的原始名称和标志位
+func readZipNames(path string) ([]string, []uint16, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开 ZIP 文件失败: %w", err)
+	}
+	defer reader.Close()
+
+	names := make([]string, len(reader.File))
+	flags := make([]uint16, len(reader.File))
+	for i, file := range reader.File {
+		names[i] = file.Name
+		flags[i] = file.Flags
+	}
+	return names, flags, nil
+}
+
+// DetectZipCharset 检测 ZIP 文件名编码
+// legacy 表示存在不是 UTF-8 编码的文件名
+func DetectZipCharset(path string) (charset string, legacy bool, err error) {
+	names, flags, err := readZipNames(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	var legacyNames []string
+	for i, name := range names {
+		if isLegacyZipName(name, flags[i]) {
+			legacyNames = append(legacyNames, name)
+		}
+	}
+	charset = detectCharset(legacyNames)
+	return charset, charset != CharsetUTF8, nil
+}
+
+// PreviewZipNames 按指定编码解码文件名用于预览，优先展示需要转换的名称
+func PreviewZipNames(path, charset string, limit int) ([]string, error) {
+	names, flags, err := readZipNames(path)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decodeZipNames(names, flags, charset)
+	if err != nil {
+		return nil, err
+	}
+
+	var preview []string
+	for i := range names {
+		if len(preview) >= limit {
+			break
+		}
+		if isLegacyZipName(names[i], flags[i]) {
+			preview = append(preview, decoded[i])
+		}
+	}
+	for i := range names {
+		if len(preview) >= limit {
+			break
+		}
+		if !isLegacyZipName(names[i], flags[i]) {
+			preview = append(preview, decoded[i])
+		}
+	}
+	return preview, nil
+}
@@ -0,0 +1,178 @@
+package archiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// extractTask 解压时的一个条目
+// 目录和父目录在准备阶段按顺序创建，工作协程只负责写入文件内容
+type extractTask struct {
+	index  int    // 条目在归档中的下标，用于进度
+	name   string // 改写和规范化后的条目路径
+	target string
+	mode   os.FileMode
+	// group 同组的文件由同一个协程按顺序写入，用于 7z 同一个固实块中的文件
+	group int
+	// open 打开条目内容，为 nil 表示目录，不需要写入
+	open func() (io.ReadCloser, error)
+}
+
+// extractResult 工作协程写入一个文件的结果
+type extractResult struct {
+	written int64
+	err     error
+}
+
+// runExtractTasks 用 opts.Workers 个协程并行写入文件，按归档顺序更新进度
+// 任一文件出错或 ctx 取消时停止其余工作并返回错误
+func runExtractTasks(ctx context.Context, tasks []extractTask, total int, opts ExtractOptions, stats *ExtractStats) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 按组划分，组的顺序为其第一个文件在归档中的顺序
+	results := make([]chan extractResult, len(tasks))
+	members := make(map[int][]int)
+	var groups []int
+	taskGroups := mergeTargetGroups(tasks)
+	for i, task := range tasks {
+		if task.open == nil {
+			continue
+		}
+		results[i] = make(chan extractResult, 1)
+		group := taskGroups[i]
+		if _, ok := members[group]; !ok {
+			groups = append(groups, group)
+		}
+		members[group] = append(members[group], i)
+	}
+
+	queue := make(chan []int)
+	go func() {
+		defer close(queue)
+		for _, group := range groups {
+			select {
+			case queue <- members[group]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range min(resolveWorkers(opts.Workers), max(len(groups), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range queue {
+				for _, i := range group {
+					if err := ctx.Err(); err != nil {
+						results[i] <- extractResult{err: err}
+						continue
+					}
+					written, err := writeExtractTask(ctx, tasks[i])
+					results[i] <- extractResult{written, err}
+				}
+			}
+		}()
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for i, task := range tasks {
+		if task.open != nil {
+			select {
+			case r := <-results[i]:
+				if r.err != nil {
+					return r.err
+				}
+				stats.ExtractedSize += r.written
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		// 更新进度，只解压部分条目时按所选条目计数
+		done := task.index + 1
+		if len(opts.Entries) > 0 {
+			done, total = i+1, len(tasks)
+			stats.TotalFiles = total
+		}
+		stats.ProcessedFiles = done
+		stats.CurrentFile = task.name
+		if opts.OnProgress != nil {
+			opts.OnProgress(done, total, task.name)
+		}
+		if opts.OnStats != nil {
+			opts.OnStats(*stats)
+		}
+	}
+
+	return nil
+}
+
+// mergeTargetGroups 返回每个任务实际所在的组
+// 写入同一目标的文件（重名条目，或未检测大小写冲突时仅大小写不同的条目）合并到同一组，
+// 由同一个协程按归档顺序写入，后面的条目覆盖前面的，避免多个协程同时截断和写入同一个文件
+func mergeTargetGroups(tasks []extractTask) []int {
+	parent := make(map[int]int)
+	var find func(group int) int
+	find = func(group int) int {
+		if p, ok := parent[group]; ok && p != group {
+			root := find(p)
+			parent[group] = root
+			return root
+		}
+		return group
+	}
+
+	owners := make(map[string]int)
+	for _, task := range tasks {
+		if task.open == nil {
+			continue
+		}
+		key := foldName(task.target)
+		owner, ok := owners[key]
+		if !ok {
+			owners[key] = task.group
+			continue
+		}
+		if a, b := find(owner), find(task.group); a != b {
+			parent[b] = a
+		}
+	}
+
+	groups := make([]int, len(tasks))
+	for i, task := range tasks {
+		groups[i] = find(task.group)
+	}
+	return groups
+}
+
+// writeExtractTask 将条目内容写入目标文件
+func writeExtractTask(ctx context.Context, task extractTask) (int64, error) {
+	rc, err := task.open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	outFile, err := os.OpenFile(task.target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, task.mode)
+	if err != nil {
+		return 0, fmt.Errorf("创建文件失败 %s: %w", task.name, err)
+	}
+
+	written, err := io.Copy(outFile, ctxReader{ctx, rc})
+	if closeErr := outFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return written, fmt.Errorf("解压文件失败 %s: %w", task.name, err)
+	}
+	return written, nil
+}
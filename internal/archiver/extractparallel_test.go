@@ -0,0 +1,126 @@
+package archiver
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestZip 按顺序写入条目，名称可以重复
+func writeTestZip(t testing.TB, path string, names, contents []string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+	for i, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contents[i])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractZipSameTarget(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "dup.zip")
+
+	// 重名条目和仅大小写不同的条目穿插在其他文件之间，最后一个同名条目的内容应当保留
+	var names, contents []string
+	for i := range 64 {
+		names = append(names, fmt.Sprintf("other/%d.txt", i), "same.txt")
+		contents = append(contents, "other", strings.Repeat(fmt.Sprintf("%02d", i), 4096))
+	}
+	writeTestZip(t, archive, names, contents)
+
+	for _, workers := range []int{1, 4, 8} {
+		output := filepath.Join(dir, fmt.Sprintf("out-%d", workers))
+		if _, err := Extract(context.Background(), ExtractOptions{Source: archive, Output: output, Workers: workers}); err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+		data, err := os.ReadFile(filepath.Join(output, "same.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := contents[len(contents)-1]; string(data) != want {
+			t.Fatalf("workers=%d: same.txt should hold the last duplicate entry, got %q...", workers, data[:8])
+		}
+	}
+}
+
+func TestMergeTargetGroups(t *testing.T) {
+	tasks := []extractTask{
+		{target: "/out/a", group: 0},
+		{target: "/out/b", group: 1},
+		{target: "/out/A", group: 2},
+		{target: "/out/c", group: 3},
+		{target: "/out/b", group: 4},
+		{target: "/out/d", group: 3},
+		{target: "/out/dir", group: 5},
+	}
+	for i := range tasks[:len(tasks)-1] {
+		tasks[i].open = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("")), nil }
+	}
+
+	got := mergeTargetGroups(tasks)
+	want := []int{0, 1, 0, 3, 1, 3, 5}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("mergeTargetGroups = %v, want %v", got, want)
+	}
+}
+
+// BenchmarkExtractZip 解压由大量小文件组成的 ZIP，比较串行与不同并行数
+func BenchmarkExtractZip(b *testing.B) {
+	dir := b.TempDir()
+	source := filepath.Join(dir, "corpus")
+	var total int64
+	for i := range 2000 {
+		path := filepath.Join(source, fmt.Sprintf("d%02d", i%20), fmt.Sprintf("f%04d.txt", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			b.Fatal(err)
+		}
+		data := []byte(strings.Repeat(fmt.Sprintf("line %d of file %d\n", i*7, i), 200))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			b.Fatal(err)
+		}
+		total += int64(len(data))
+	}
+	archive := filepath.Join(dir, "corpus.zip")
+	if _, err := Compress(context.Background(), CompressOptions{Source: source, Output: archive, Format: ".zip"}); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bc := range []struct {
+		name    string
+		workers int
+	}{{"serial", 1}, {"workers=4", 4}, {"workers=8", 8}} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(total)
+			for i := 0; i < b.N; i++ {
+				output := filepath.Join(dir, fmt.Sprintf("out-%s-%d", bc.name, i))
+				if _, err := Extract(context.Background(), ExtractOptions{Source: archive, Output: output, Workers: bc.workers}); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				if err := os.RemoveAll(output); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+		})
+	}
+}
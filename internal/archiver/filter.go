@@ -0,0 +1,133 @@
+package archiver
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+)
+
+// 文件类型
+const (
+	FileTypeRegular = "regular"
+	FileTypeSymlink = "symlink"
+)
+
+// 跳过原因
+const (
+	SkipExcluded    = "excluded"     // 匹配排除模式
+	SkipIgnored     = "ignored"      // 匹配忽略文件规则
+	SkipNotIncluded = "not-included" // 不匹配包含模式
+	SkipSize        = "size"         // 超出大小范围
+	SkipAge         = "age"          // 修改时间超出范围
+	SkipType        = "type"         // 文件类型不符
+	SkipBinary      = "binary"       // 二进制文件
+	SkipFilter      = "filter"       // 自定义过滤函数
+)
+
+// SkippedFile 收集文件时被跳过的文件或目录
+type SkippedFile struct {
+	Path   string
+	Reason string
+}
+
+// binarySniffSize 判断二进制文件时读取的字节数，与 git 相同
+const binarySniffSize = 8000
+
+// hasFileFilters 是否设置了任何文件过滤条件
+func hasFileFilters(opts CompressOptions) bool {
+	return opts.MinSize > 0 || opts.MaxSize > 0 || !opts.NewerThan.IsZero() || !opts.OlderThan.IsZero() ||
+		len(opts.FileTypes) > 0 || opts.SkipBinary || opts.Filter != nil
+}
+
+// fileFilterReason 按大小、时间、类型和自定义函数筛选条目
+// 返回跳过原因，保留时返回空字符串
+func fileFilterReason(path string, d fs.DirEntry, opts CompressOptions) (string, error) {
+	if opts.Filter != nil && !opts.Filter(path, d) {
+		return SkipFilter, nil
+	}
+	if d.IsDir() {
+		return "", nil
+	}
+
+	if len(opts.FileTypes) > 0 && !slices.Contains(opts.FileTypes, entryFileType(d)) {
+		return SkipType, nil
+	}
+
+	if opts.MinSize > 0 || opts.MaxSize > 0 || !opts.NewerThan.IsZero() || !opts.OlderThan.IsZero() {
+		info, err := d.Info()
+		if err != nil {
+			return "", err
+		}
+		if opts.MinSize > 0 && info.Size() < opts.MinSize {
+			return SkipSize, nil
+		}
+		if opts.MaxSize > 0 && info.Size() > opts.MaxSize {
+			return SkipSize, nil
+		}
+		if !opts.NewerThan.IsZero() && !info.ModTime().After(opts.NewerThan) {
+			return SkipAge, nil
+		}
+		if !opts.OlderThan.IsZero() && !info.ModTime().Before(opts.OlderThan) {
+			return SkipAge, nil
+		}
+	}
+
+	if opts.SkipBinary && d.Type().IsRegular() {
+		binary, err := isBinaryFile(path)
+		if err != nil {
+			return "", err
+		}
+		if binary {
+			return SkipBinary, nil
+		}
+	}
+
+	return "", nil
+}
+
+// entryFileType 返回条目的文件类型，无法归类时返回空字符串
+func entryFileType(d fs.DirEntry) string {
+	switch {
+	case d.Type().IsRegular():
+		return FileTypeRegular
+	case d.Type()&fs.ModeSymlink != 0:
+		return FileTypeSymlink
+	}
+	return ""
+}
+
+// keepSymlinks 按类型筛选并选中符号链接时，链接本身作为链接条目写入，而不是读取目标的内容
+func keepSymlinks(opts CompressOptions) bool {
+	return slices.Contains(opts.FileTypes, FileTypeSymlink)
+}
+
+// lstatSymlink 返回符号链接自身的信息和目标，不是符号链接时 info 为 nil
+func lstatSymlink(path string) (fs.FileInfo, string, error) {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return nil, "", err
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return info, target, nil
+}
+
+// isBinaryFile 文件开头包含 NUL 字节时视为二进制文件
+func isBinaryFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, binarySniffSize)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return bytes.IndexByte(buf[:n], 0) >= 0, nil
+}
@@ -0,0 +1,112 @@
+package archiver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pathMatcher 按 Excludes / Includes 模式筛选文件
+// 模式语法与 gitignore 相同：
+//   - 不含斜杠的模式匹配任意层级的文件或目录名，如 node_modules、*.log
+//   - 以 / 开头或中间含斜杠的模式相对源目录，如 /build、docs/*.pdf
+//   - ** 匹配任意层级目录，如 src/**/testdata
+//   - 以 ! 开头的模式重新包含之前排除的路径，如 !keep.log
+//   - 以 / 结尾的模式只匹配目录
+type pathMatcher struct {
+	excludes []ignoreRule
+	includes []ignoreRule
+}
+
+// newPathMatcher 编译排除和包含模式
+func newPathMatcher(excludes, includes []string) *pathMatcher {
+	return &pathMatcher{
+		excludes: compilePatterns(excludes),
+		includes: compilePatterns(includes),
+	}
+}
+
+// ValidatePattern 检查排除或包含模式是否有效
+func ValidatePattern(pattern string) error {
+	trimmed := strings.TrimSpace(pattern)
+	switch {
+	case trimmed == "":
+		return fmt.Errorf("模式不能为空")
+	case strings.HasPrefix(trimmed, "#"):
+		return fmt.Errorf("以 # 开头的模式会被视为注释，请写作 \\#")
+	case strings.Trim(trimmed, "!/") == "":
+		return fmt.Errorf("模式缺少文件名: %s", pattern)
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(trimmed, "!"), "/") {
+		if part == ".." {
+			return fmt.Errorf("模式不能包含 ..: %s", pattern)
+		}
+	}
+
+	if _, _, err := compileIgnoreLine(pattern); err != nil {
+		return fmt.Errorf("无效的模式 %s: %w", pattern, err)
+	}
+	return nil
+}
+
+// compilePatterns 编译模式列表，忽略空模式和注释
+func compilePatterns(patterns []string) []ignoreRule {
+	var rules []ignoreRule
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreLine(pattern); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// matchRules 按顺序应用规则，最后一条匹配的规则决定结果
+func matchRules(rules []ignoreRule, rel string, isDir, matched bool) bool {
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			matched = !rule.negate
+		}
+	}
+	return matched
+}
+
+// excluded 判断相对源目录的路径是否被排除
+func (p *pathMatcher) excluded(rel string, isDir bool) bool {
+	return matchRules(p.excludes, rel, isDir, false)
+}
+
+// included 判断文件是否满足包含模式，未设置包含模式时总是满足
+// 文件本身或任一上级目录匹配即可
+func (p *pathMatcher) included(rel string) bool {
+	if len(p.includes) == 0 {
+		return true
+	}
+	if matchRules(p.includes, rel, false, false) {
+		return true
+	}
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' && matchRules(p.includes, rel[:i], true, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// sevenZipExcludeArgs 将排除模式转换为 7z 的 -xr! 参数
+// 7z 只支持匹配文件名的 * 和 ?，存在无法转换的模式时返回 false
+func sevenZipExcludeArgs(patterns []string) ([]string, bool) {
+	var args []string
+	for _, pattern := range patterns {
+		pattern = trimIgnoreTrailingSpace(strings.TrimSuffix(pattern, "\r"))
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if strings.ContainsAny(pattern, `/[]\!`) || strings.Contains(pattern, "**") {
+			return nil, false
+		}
+		args = append(args, "-xr!"+pattern)
+	}
+	return args, true
+}
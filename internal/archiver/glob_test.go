@@ -0,0 +1,112 @@
+package archiver
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPathMatcherExcluded(t *testing.T) {
+	for _, tc := range []struct {
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		// ** 匹配零或多级目录，只在源目录的 src 下
+		{[]string{"src/**/testdata"}, "src/testdata", true, true},
+		{[]string{"src/**/testdata"}, "src/a/b/testdata", true, true},
+		{[]string{"src/**/testdata"}, "lib/testdata", true, false},
+		{[]string{"src/**/testdata"}, "lib/src/testdata", true, false},
+		{[]string{"build/**"}, "build/a/b.o", false, true},
+		{[]string{"build/**"}, "build", true, false},
+
+		// 开头的 / 只匹配源目录下的一级，不含斜杠的模式匹配任意层级
+		{[]string{"/build"}, "build", true, true},
+		{[]string{"/build"}, "sub/build", true, false},
+		{[]string{"build"}, "sub/build", true, true},
+		{[]string{"node_modules"}, "a/b/node_modules", true, true},
+
+		// 中间含斜杠的模式相对源目录，* 不跨越目录
+		{[]string{"docs/*.pdf"}, "docs/a.pdf", false, true},
+		{[]string{"docs/*.pdf"}, "docs/sub/a.pdf", false, false},
+		{[]string{"docs/*.pdf"}, "other/docs/a.pdf", false, false},
+
+		// 取反的模式重新包含，最后匹配的规则生效
+		{[]string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{[]string{"*.log", "!keep.log"}, "logs/keep.log", false, false},
+		{[]string{"*.log", "!keep.log"}, "debug.log", false, true},
+		{[]string{"!keep.log", "*.log"}, "keep.log", false, true},
+
+		// 字符类和 ?
+		{[]string{"[xy].txt"}, "x.txt", false, true},
+		{[]string{"[xy].txt"}, "y.txt", false, true},
+		{[]string{"[xy].txt"}, "z.txt", false, false},
+		{[]string{"[!xy].txt"}, "z.txt", false, true},
+		{[]string{"[!xy].txt"}, "x.txt", false, false},
+		{[]string{"file?.txt"}, "file1.txt", false, true},
+		{[]string{"file?.txt"}, "file10.txt", false, false},
+		{[]string{"file?.txt"}, "file/.txt", false, false},
+
+		// 以 / 结尾的模式只匹配目录
+		{[]string{"tmp/"}, "tmp", true, true},
+		{[]string{"tmp/"}, "tmp", false, false},
+		{[]string{"tmp/"}, "a/tmp", true, true},
+
+		// 转义和注释
+		{[]string{`\#notes`}, "#notes", false, true},
+		{[]string{"#notes"}, "#notes", false, false},
+		{[]string{`\!important`}, "!important", false, true},
+	} {
+		m := newPathMatcher(tc.patterns, nil)
+		if got := m.excluded(tc.path, tc.isDir); got != tc.want {
+			t.Errorf("patterns %q: excluded(%q, dir=%v) = %v, want %v", tc.patterns, tc.path, tc.isDir, got, tc.want)
+		}
+	}
+}
+
+func TestPathMatcherIncluded(t *testing.T) {
+	for _, tc := range []struct {
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{nil, "any/file.txt", true},
+		{[]string{"*.go"}, "cmd/main.go", true},
+		{[]string{"*.go"}, "README.md", false},
+		// 上级目录匹配时包含其中的所有文件
+		{[]string{"docs"}, "docs/a/b.txt", true},
+		{[]string{"/docs"}, "sub/docs/b.txt", false},
+		{[]string{"src/**/testdata"}, "src/a/testdata/in.txt", true},
+		{[]string{"docs/*.pdf"}, "docs/sub/a.pdf", false},
+		{[]string{"*.txt", "!secret.txt"}, "secret.txt", false},
+		{[]string{"[xy].txt"}, "dir/y.txt", true},
+	} {
+		m := newPathMatcher(nil, tc.patterns)
+		if got := m.included(tc.path); got != tc.want {
+			t.Errorf("patterns %q: included(%q) = %v, want %v", tc.patterns, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestSevenZipExcludeArgs(t *testing.T) {
+	for _, tc := range []struct {
+		patterns []string
+		want     []string
+		ok       bool
+	}{
+		{[]string{"*.log", "node_modules", "file?.txt"}, []string{"-xr!*.log", "-xr!node_modules", "-xr!file?.txt"}, true},
+		{[]string{"", "# comment", "*.tmp  "}, []string{"-xr!*.tmp"}, true},
+		{[]string{"*.log", "src/**/testdata"}, nil, false},
+		{[]string{"/build"}, nil, false},
+		{[]string{"docs/*.pdf"}, nil, false},
+		{[]string{"*.log", "!keep.log"}, nil, false},
+		{[]string{"[xy].txt"}, nil, false},
+		{[]string{"tmp/"}, nil, false},
+		{[]string{`\#notes`}, nil, false},
+	} {
+		got, ok := sevenZipExcludeArgs(tc.patterns)
+		if ok != tc.ok || !slices.Equal(got, tc.want) {
+			t.Errorf("sevenZipExcludeArgs(%q) = %q, %v, want %q, %v", tc.patterns, got, ok, tc.want, tc.ok)
+		}
+	}
+}
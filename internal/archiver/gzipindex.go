@@ -0,0 +1,482 @@
+package archiver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Gzip 随机访问索引（与 zlib 的 zran、gztool 的思路相同）：
+// 每隔约 gzipIndexSpan 字节的输出，在 Deflate 块的开头记录压缩流中的位位置和之前 32KB 的输出，
+// 从检查点开始可以直接解码，不必从头解压；索引还包含 TAR 条目的位置，列出和选择性解压只需读取索引
+
+const (
+	gzipIndexVersion = 1
+	gzipIndexSpan    = 1 << 20 // 检查点之间的输出字节数，每个检查点保存 32KB 窗口
+	gzipTrailerSize  = 8
+)
+
+// GzipIndexPath 旁路 Gzip 索引文件的路径
+func GzipIndexPath(archive string) string {
+	return archive + ".gzidx"
+}
+
+// GzipIndexStats 建立的 Gzip 索引
+type GzipIndexStats struct {
+	Path        string // 索引文件路径
+	Size        int64  // 索引文件大小
+	Checkpoints int    // 检查点数
+	Entries     int    // TAR 条目数
+}
+
+// gzipCheckpoint 可以开始解码的位置
+type gzipCheckpoint struct {
+	In     int64  // Deflate 块开始的字节在归档中的位置
+	Bits   uint8  // 块从该字节的第几位开始（0-7）
+	Out    int64  // 对应的未压缩位置
+	Window []byte // 之前最多 32KB 的输出，成员的开头为空
+}
+
+// gzipIndex 保存在旁路文件中的索引
+type gzipIndex struct {
+	Version     int
+	Size        int64  // 归档大小
+	Trailer     []byte // 归档最后 8 字节（CRC32 和长度），与 Size 一起判断索引是否过期
+	Checkpoints []gzipCheckpoint
+	Tar         *tarIndex
+}
+
+// BuildGzipIndex 解压一遍 TAR.GZ 归档，在归档旁写入随机访问索引
+func BuildGzipIndex(ctx context.Context, archive string) (*GzipIndexStats, error) {
+	if DetectArchiveFormat(archive) != ".tar.gz" {
+		return nil, fmt.Errorf("只能为 TAR.GZ 归档建立索引: %s", archive)
+	}
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	index, err := scanGzipIndex(ctxReader{ctx: ctx, r: file})
+	if err != nil {
+		return nil, err
+	}
+	return writeGzipIndex(index, GzipIndexPath(archive))
+}
+
+// gzipIndexer 在压缩时解析写入的 Gzip 流，与压缩同时建立索引
+type gzipIndexer struct {
+	pw    *io.PipeWriter
+	done  chan struct{}
+	index *gzipIndex
+	err   error
+}
+
+// newGzipIndexer 创建索引器，写入的数据在另一个协程中解析
+func newGzipIndexer() *gzipIndexer {
+	pr, pw := io.Pipe()
+	ix := &gzipIndexer{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(ix.done)
+		ix.index, ix.err = scanGzipIndex(pr)
+		// 解析失败后继续读取剩余数据，避免阻塞写入方
+		io.Copy(io.Discard, pr)
+	}()
+	return ix
+}
+
+func (ix *gzipIndexer) Write(p []byte) (int, error) {
+	return ix.pw.Write(p)
+}
+
+// Finish 结束写入并返回索引，可以重复调用
+func (ix *gzipIndexer) Finish() (*gzipIndex, error) {
+	ix.pw.Close()
+	<-ix.done
+	return ix.index, ix.err
+}
+
+// scanGzipIndex 解码整个 Gzip 流（可以有多个成员），记录检查点和 TAR 条目
+func scanGzipIndex(r io.Reader) (*gzipIndex, error) {
+	index := &gzipIndex{Version: gzipIndexVersion}
+	tarIndexer := newTarIndexer()
+	defer tarIndexer.Finish()
+
+	crc := crc32.NewIEEE()
+	trailer := make([]byte, gzipTrailerSize)
+	br := &bitReader{r: bufio.NewReaderSize(r, 256<<10)}
+	f := &inflater{br: br}
+	f.emit = func(p []byte) error {
+		crc.Write(p)
+		_, err := tarIndexer.Write(p)
+		return err
+	}
+	f.onBlock = func() error {
+		last := index.Checkpoints[len(index.Checkpoints)-1]
+		if f.pos-last.Out < gzipIndexSpan {
+			return nil
+		}
+		bit := br.bitPos()
+		index.Checkpoints = append(index.Checkpoints, gzipCheckpoint{
+			In:     bit / 8,
+			Bits:   uint8(bit % 8),
+			Out:    f.pos,
+			Window: bytes.Clone(f.window()),
+		})
+		return nil
+	}
+
+	for member := 0; member == 0 || !br.atEOF(); member++ {
+		if err := readGzipHeader(br); err != nil {
+			return nil, fmt.Errorf("读取 Gzip 头失败: %w", err)
+		}
+		index.Checkpoints = append(index.Checkpoints, gzipCheckpoint{In: br.bitPos() / 8, Out: f.pos})
+
+		crc.Reset()
+		start := f.pos
+		if err := f.inflate(); err != nil {
+			return nil, fmt.Errorf("解压 Gzip 数据失败: %w", err)
+		}
+		if err := f.reset(); err != nil {
+			return nil, err
+		}
+
+		for i := range trailer {
+			c, err := br.readByte()
+			if err != nil {
+				return nil, fmt.Errorf("读取 Gzip 尾部失败: %w", err)
+			}
+			trailer[i] = c
+		}
+		if binary.LittleEndian.Uint32(trailer[0:]) != crc.Sum32() || binary.LittleEndian.Uint32(trailer[4:]) != uint32(f.pos-start) {
+			return nil, fmt.Errorf("Gzip 校验和不匹配，归档已损坏")
+		}
+	}
+
+	index.Size = br.pos
+	index.Trailer = bytes.Clone(trailer)
+	tar, err := tarIndexer.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("生成 TAR 索引失败: %w", err)
+	}
+	index.Tar = tar
+	return index, nil
+}
+
+// readGzipHeader 读取并跳过 Gzip 成员头（RFC 1952）
+func readGzipHeader(br *bitReader) error {
+	header := make([]byte, 10)
+	for i := range header {
+		c, err := br.readByte()
+		if err != nil {
+			return err
+		}
+		header[i] = c
+	}
+	if header[0] != 0x1f || header[1] != 0x8b || header[2] != 8 {
+		return gzip.ErrHeader
+	}
+
+	flags := header[3]
+	if flags&0x04 != 0 { // FEXTRA
+		lo, err := br.readByte()
+		if err != nil {
+			return err
+		}
+		hi, err := br.readByte()
+		if err != nil {
+			return err
+		}
+		for n := int(lo) | int(hi)<<8; n > 0; n-- {
+			if _, err := br.readByte(); err != nil {
+				return err
+			}
+		}
+	}
+	for _, flag := range []byte{0x08, 0x10} { // FNAME、FCOMMENT 以 0 结尾
+		if flags&flag == 0 {
+			continue
+		}
+		for {
+			c, err := br.readByte()
+			if err != nil {
+				return err
+			}
+			if c == 0 {
+				break
+			}
+		}
+	}
+	if flags&0x02 != 0 { // FHCRC
+		for range 2 {
+			if _, err := br.readByte(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeGzipIndex 将索引以 gob 编码、Gzip 压缩后写入 path
+func writeGzipIndex(index *gzipIndex, path string) (*GzipIndexStats, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gzidx-*")
+	if err != nil {
+		return nil, fmt.Errorf("写入 Gzip 索引失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := gzip.NewWriter(tmp)
+	if err := gob.NewEncoder(zw).Encode(index); err != nil {
+		return nil, fmt.Errorf("写入 Gzip 索引失败: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("写入 Gzip 索引失败: %w", err)
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("写入 Gzip 索引失败: %w", err)
+	}
+	return &GzipIndexStats{
+		Path:        path,
+		Size:        info.Size(),
+		Checkpoints: len(index.Checkpoints),
+		Entries:     len(index.Tar.Entries),
+	}, nil
+}
+
+// loadGzipIndex 读取归档旁的索引，没有索引、索引无法读取或归档已被修改时返回 nil
+func loadGzipIndex(file *os.File, source string) (*gzipIndex, error) {
+	// 索引只用于加速，无法读取、已损坏或版本不同时都按没有索引处理
+	indexFile, err := os.Open(GzipIndexPath(source))
+	if err != nil {
+		return nil, nil
+	}
+	defer indexFile.Close()
+
+	zr, err := gzip.NewReader(indexFile)
+	if err != nil {
+		return nil, nil
+	}
+	var index gzipIndex
+	if err := gob.NewDecoder(zr).Decode(&index); err != nil {
+		return nil, nil
+	}
+	if index.Version != gzipIndexVersion || index.Tar == nil || !validGzipCheckpoints(index.Checkpoints, index.Size) {
+		return nil, nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, gzipTrailerSize)
+	if info.Size() != index.Size || info.Size() < gzipTrailerSize {
+		return nil, nil
+	}
+	if _, err := file.ReadAt(trailer, info.Size()-gzipTrailerSize); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(trailer, index.Trailer) {
+		return nil, nil
+	}
+	return &index, nil
+}
+
+// validGzipCheckpoints 检查检查点按顺序排列且位置都在归档内（空成员的输出位置可以相同），从字节中间开始的块需要窗口
+func validGzipCheckpoints(checkpoints []gzipCheckpoint, size int64) bool {
+	if len(checkpoints) == 0 {
+		return false
+	}
+	for i, cp := range checkpoints {
+		if cp.In < 0 || cp.In >= size || cp.Bits > 7 || len(cp.Window) > 32<<10 ||
+			(cp.Bits != 0 && len(cp.Window) == 0) {
+			return false
+		}
+		if i > 0 && (cp.Out < checkpoints[i-1].Out || cp.In <= checkpoints[i-1].In) {
+			return false
+		}
+	}
+	return checkpoints[0].Out == 0
+}
+
+// gzipSeekable 按未压缩位置读取带索引的 Gzip 归档
+type gzipSeekable struct {
+	file  *os.File
+	index *gzipIndex
+
+	// 当前的解码位置，后面的区段离得不远时继续向后解码，不回到检查点
+	reader io.Reader
+	pos    int64
+	cp     int // 当前解码开始的检查点
+}
+
+// section 读取未压缩流中 [start, end) 的内容
+func (s *gzipSeekable) section(start, end int64) io.Reader {
+	return &gzipSection{s: s, pos: start, end: end}
+}
+
+// seek 使当前解码位置到达 pos
+func (s *gzipSeekable) seek(pos int64) error {
+	if s.reader == nil || pos < s.pos || pos-s.pos > gzipIndexSpan {
+		// 从 pos 之前最近的检查点开始解码
+		checkpoints := s.index.Checkpoints
+		i := sort.Search(len(checkpoints), func(i int) bool { return checkpoints[i].Out > pos }) - 1
+		if err := s.start(max(i, 0)); err != nil {
+			return err
+		}
+	}
+	if _, err := io.CopyN(io.Discard, s, pos-s.pos); err != nil {
+		return fmt.Errorf("解压 Gzip 数据失败: %w", err)
+	}
+	return nil
+}
+
+// start 从第 i 个检查点开始解码
+func (s *gzipSeekable) start(i int) error {
+	cp := s.index.Checkpoints[i]
+	r := io.NewSectionReader(s.file, cp.In, s.index.Size-cp.In)
+	if cp.Bits == 0 {
+		s.reader, s.pos, s.cp = flate.NewReaderDict(bufio.NewReader(r), cp.Window), cp.Out, i
+		return nil
+	}
+
+	// 块从字节中间开始：用一个合成的块补齐前面的位，该块输出窗口的最后一个字节，
+	// 因此字典取窗口的其余部分，解码历史与原始流一致，之后的存储块也保持原来的字节对齐
+	first := make([]byte, 1)
+	if _, err := r.Read(first); err != nil {
+		return fmt.Errorf("解压 Gzip 数据失败: %w", err)
+	}
+	last := len(cp.Window) - 1
+	prefix := deflatePrefix(cp.Window[last], cp.Bits, first[0])
+	stream := io.MultiReader(bytes.NewReader(prefix), r)
+	s.reader, s.pos, s.cp = flate.NewReaderDict(bufio.NewReader(stream), cp.Window[:last]), cp.Out-1, i
+	return nil
+}
+
+// deflatePrefix 生成一个非最终的动态 Huffman 块，只输出字面量 literal，长度为 8 的整数倍加 bits 位
+// 最后一个字节的高位换成 next（原始流中块开始的字节）的高位
+// 码长码的个数（HCLEN）每多一个增加 3 位，总能找到满足长度要求的个数
+func deflatePrefix(literal byte, bits uint8, next byte) []byte {
+	// 字面量 0-254 码长 8，255 和块结束符 256 码长 9；码长码 8 为 1 位，0 和 9 为 2 位
+	litLen := uint(8)
+	if literal == 255 {
+		litLen = 9
+	}
+	n := 7
+	for (287+litLen+3*uint(n))%8 != uint(bits) {
+		n++
+	}
+
+	w := &bitWriter{}
+	w.write(0b100, 3) // BFINAL=0，BTYPE=10
+	w.write(0, 5)     // HLIT：257 个字面量/长度码
+	w.write(0, 5)     // HDIST：1 个距离码
+	w.write(uint32(n-4), 4)
+	for _, sym := range deflateCodeOrder[:n] {
+		switch sym {
+		case 8:
+			w.write(1, 3)
+		case 0, 9:
+			w.write(2, 3)
+		default:
+			w.write(0, 3)
+		}
+	}
+	for sym := range 257 {
+		if sym < 255 {
+			w.writeCode(0b0, 1) // 码长 8
+		} else {
+			w.writeCode(0b11, 2) // 码长 9
+		}
+	}
+	w.writeCode(0b10, 2) // 距离码不使用
+
+	if literal == 255 {
+		w.writeCode(510, 9)
+	} else {
+		w.writeCode(uint32(literal), 8)
+	}
+	w.writeCode(511, 9) // 块结束符
+	return append(w.buf, byte(w.bits)|next&^(1<<bits-1))
+}
+
+// bitWriter 按 Deflate 的位序写入
+type bitWriter struct {
+	buf  []byte
+	bits uint64
+	nb   uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	w.bits |= uint64(v) << w.nb
+	w.nb += n
+	for w.nb >= 8 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits >>= 8
+		w.nb -= 8
+	}
+}
+
+// writeCode 写入 Huffman 码（码的高位在前）
+func (w *bitWriter) writeCode(code uint32, n uint) {
+	w.write(uint32(reverseBits(int(code), n)), n)
+}
+
+// Read 从当前位置读取，Gzip 成员结束时从下一个成员开头的检查点继续
+func (s *gzipSeekable) Read(p []byte) (int, error) {
+	n, err := s.reader.Read(p)
+	s.pos += int64(n)
+	if err == io.EOF {
+		checkpoints := s.index.Checkpoints
+		i := s.cp + 1 + sort.Search(len(checkpoints)-s.cp-1, func(i int) bool { return checkpoints[s.cp+1+i].Out >= s.pos })
+		for ; i < len(checkpoints) && checkpoints[i].Out == s.pos; i++ {
+			if len(checkpoints[i].Window) == 0 && checkpoints[i].Bits == 0 {
+				return n, s.start(i)
+			}
+		}
+	}
+	return n, err
+}
+
+// gzipSection 未压缩流中的一段，读取时才解码
+type gzipSection struct {
+	s        *gzipSeekable
+	pos, end int64
+}
+
+func (r *gzipSection) Read(p []byte) (int, error) {
+	if r.pos >= r.end {
+		return 0, io.EOF
+	}
+	if r.s.reader == nil || r.s.pos != r.pos {
+		if err := r.s.seek(r.pos); err != nil {
+			return 0, err
+		}
+	}
+	p = p[:min(int64(len(p)), r.end-r.pos)]
+	n, err := r.s.Read(p)
+	r.pos += int64(n)
+	if err == io.EOF && r.pos < r.end {
+		err = io.ErrUnexpectedEOF
+	} else if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
@@ -0,0 +1,219 @@
+package archiver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// gzipTestTar 生成数 MB 的 TAR，文本和随机数据交替，解压后跨越多个检查点
+func gzipTestTar(t *testing.T) ([]byte, int) {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	data := xzTestData(5 << 20)
+	count := 0
+	for off, size := 0, 1000; off < len(data); off, size = off+size, size*3%(700<<10)+1 {
+		content := data[off:min(off+size, len(data))]
+		header := &tar.Header{Name: fmt.Sprintf("dir/file%03d", count), Mode: 0644, Size: int64(len(content)), Format: tar.FormatPAX}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), count
+}
+
+// gzipCompress 用标准库按 level 压缩，parts 大于 1 时把数据分成多个成员
+func gzipCompress(t *testing.T, data []byte, level, parts int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	step := (len(data) + parts - 1) / parts
+	for off := 0; off < len(data); off += step {
+		zw, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := zw.Write(data[off:min(off+step, len(data))]); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestGzipIndexCheckpoints(t *testing.T) {
+	raw, count := gzipTestTar(t)
+
+	var pgz bytes.Buffer
+	gzWriter, err := newGzipWriter(&pgz, CompressOptions{GzipBlockSize: 256 << 10, Workers: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gzWriter.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name       string
+		compressed []byte
+	}{
+		{"level1", gzipCompress(t, raw, gzip.BestSpeed, 1)},
+		{"level6", gzipCompress(t, raw, gzip.DefaultCompression, 1)},
+		{"level9", gzipCompress(t, raw, gzip.BestCompression, 1)},
+		{"stored", gzipCompress(t, raw, gzip.NoCompression, 1)},
+		{"members", gzipCompress(t, raw, gzip.DefaultCompression, 3)},
+		{"pgzip", pgz.Bytes()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// 参考实现解压的结果
+			zr, err := gzip.NewReader(bytes.NewReader(tc.compressed))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(want, raw) {
+				t.Fatal("gzip.NewReader output differs from the input")
+			}
+
+			index, err := scanGzipIndex(bytes.NewReader(tc.compressed))
+			if err != nil {
+				t.Fatalf("scanGzipIndex: %v", err)
+			}
+			if len(index.Checkpoints) < 4 {
+				t.Fatalf("got %d checkpoints, want at least 4", len(index.Checkpoints))
+			}
+			if len(index.Tar.Entries) != count {
+				t.Fatalf("TAR index has %d entries, want %d", len(index.Tar.Entries), count)
+			}
+			if index.Size != int64(len(tc.compressed)) || !validGzipCheckpoints(index.Checkpoints, index.Size) {
+				t.Fatalf("index does not describe the archive: size %d, %d checkpoints", index.Size, len(index.Checkpoints))
+			}
+
+			// 边写边解析的索引与单独扫描的相同
+			indexer := newGzipIndexer()
+			for off := 0; off < len(tc.compressed); off += 100_000 {
+				if _, err := indexer.Write(tc.compressed[off:min(off+100_000, len(tc.compressed))]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			streamed, err := indexer.Finish()
+			if err != nil {
+				t.Fatalf("gzipIndexer: %v", err)
+			}
+			if len(streamed.Checkpoints) != len(index.Checkpoints) {
+				t.Fatalf("gzipIndexer found %d checkpoints, scanGzipIndex %d", len(streamed.Checkpoints), len(index.Checkpoints))
+			}
+
+			path := filepath.Join(dir, tc.name+".tar.gz")
+			if err := os.WriteFile(path, tc.compressed, 0644); err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			// 从每个检查点开始解码到流末尾，多成员时自动进入下一个成员
+			// 块从字节中间开始时，合成的块先输出窗口的最后一个字节
+			s := &gzipSeekable{file: file, index: index}
+			for i, cp := range index.Checkpoints {
+				if err := s.start(i); err != nil {
+					t.Fatalf("checkpoint %d: %v", i, err)
+				}
+				if want := cp.Out - int64(min(cp.Bits, 1)); s.pos != want {
+					t.Fatalf("checkpoint %d (bits %d) starts at %d, want %d", i, cp.Bits, s.pos, want)
+				}
+				from := s.pos
+				got, err := io.ReadAll(s)
+				if err != nil {
+					t.Fatalf("checkpoint %d (in %d, bits %d): %v", i, cp.In, cp.Bits, err)
+				}
+				if !bytes.Equal(got, raw[from:]) {
+					t.Fatalf("checkpoint %d (in %d, bits %d) decodes to different data", i, cp.In, cp.Bits)
+				}
+			}
+
+			// 按条目的位置读取一段，不按顺序，需要回到检查点
+			s = &gzipSeekable{file: file, index: index}
+			for _, i := range []int{count - 1, 0, count / 2} {
+				entry := index.Tar.Entries[i]
+				got, err := io.ReadAll(s.section(entry.Offset, entry.Offset+entry.Size))
+				if err != nil {
+					t.Fatalf("entry %s: %v", entry.Name, err)
+				}
+				if !bytes.Equal(got, raw[entry.Offset:entry.Offset+entry.Size]) {
+					t.Fatalf("entry %s has wrong content", entry.Name)
+				}
+			}
+		})
+	}
+}
+
+// fixedHuffmanLiteral 固定 Huffman 编码的字面量
+func fixedHuffmanLiteral(w *bitWriter, lit byte) {
+	if lit < 144 {
+		w.writeCode(0x30+uint32(lit), 8)
+	} else {
+		w.writeCode(0x190+uint32(lit-144), 9)
+	}
+}
+
+func TestDeflatePrefix(t *testing.T) {
+	stored := []byte("stored block after the fixed block")
+	for bits := range uint8(8) {
+		for last := range 256 {
+			window := []byte{'w', 'X', byte(last)}
+			lit := byte(255 - last)
+
+			// 原始流：前面的块占用 bits 位，之后是一个固定 Huffman 块，
+			// 先复制距离为 2、长度为 3 的内容（引用窗口），再输出 lit，最后是对齐的最终存储块
+			w := &bitWriter{}
+			w.write(0x5a&(1<<bits-1), uint(bits))
+			w.write(0b010, 3) // BFINAL=0，BTYPE=01
+			w.writeCode(1, 7) // 长度 3
+			w.writeCode(1, 5) // 距离 2
+			fixedHuffmanLiteral(w, lit)
+			w.writeCode(0, 7) // 块结束符
+			w.write(0b001, 3) // BFINAL=1，BTYPE=00
+			if w.nb > 0 {
+				w.write(0, 8-w.nb)
+			}
+			original := append(w.buf, byte(len(stored)), 0, ^byte(len(stored)), 0xff)
+			original = append(original, stored...)
+
+			prefix := deflatePrefix(window[len(window)-1], bits, original[0])
+			stream := append(prefix, original[1:]...)
+			got, err := io.ReadAll(flate.NewReaderDict(bytes.NewReader(stream), window[:len(window)-1]))
+			if err != nil {
+				t.Fatalf("bits %d, literal %d: %v", bits, last, err)
+			}
+			want := append([]byte{byte(last), 'X', byte(last), 'X', lit}, stored...)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("bits %d, literal %d: got %q, want %q", bits, last, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package archiver
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileNames RespectIgnoreFiles 模式下读取的忽略文件，靠后的优先级更高
+var IgnoreFileNames = []string{".gitignore", ".ignore", ".archiveignore"}
+
+// ignoreRule 一条 gitignore 规则
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // 以 ! 开头，重新包含已忽略的路径
+	dirOnly bool // 以 / 结尾，只匹配目录
+}
+
+// ignoreMatcher 按目录层级应用忽略文件
+// 与 git 相同：深层目录的规则优先，同一文件中靠后的规则优先
+type ignoreMatcher struct {
+	root string
+	// 相对 root 的目录（斜杠分隔，根目录为 ""）→ 该目录下的规则
+	dirs map[string][]ignoreRule
+}
+
+// newIgnoreMatcher 创建忽略规则匹配器
+func newIgnoreMatcher(root string) *ignoreMatcher {
+	return &ignoreMatcher{root: root, dirs: make(map[string][]ignoreRule)}
+}
+
+// loadDir 读取目录下的忽略文件，rel 为相对 root 的斜杠路径
+func (m *ignoreMatcher) loadDir(rel string) error {
+	var rules []ignoreRule
+	for _, name := range IgnoreFileNames {
+		file := filepath.Join(m.root, filepath.FromSlash(rel), name)
+		parsed, err := parseIgnoreFile(file)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, parsed...)
+	}
+	if len(rules) > 0 {
+		m.dirs[rel] = rules
+	}
+	return nil
+}
+
+// match 判断相对 root 的路径是否被忽略
+func (m *ignoreMatcher) match(rel string, isDir bool) bool {
+	// 从根目录到父目录依次应用各级规则
+	dirs := []string{""}
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' {
+			dirs = append(dirs, rel[:i])
+		}
+	}
+
+	ignored := false
+	for _, dir := range dirs {
+		sub := rel
+		if dir != "" {
+			sub = rel[len(dir)+1:]
+		}
+		ignored = matchRules(m.dirs[dir], sub, isDir, ignored)
+	}
+	return ignored
+}
+
+// parseIgnoreFile 解析忽略文件，文件不存在时返回空规则
+func parseIgnoreFile(file string) ([]ignoreRule, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取忽略文件失败: %w", err)
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		rule, ok := parseIgnoreLine(scanner.Text())
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取忽略文件失败 %s: %w", file, err)
+	}
+	return rules, nil
+}
+
+// parseIgnoreLine 解析一行 gitignore 规则，空行、注释和无效规则返回 false
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	rule, ok, err := compileIgnoreLine(line)
+	return rule, ok && err == nil
+}
+
+// compileIgnoreLine 编译一行 gitignore 规则，空行和注释返回 false
+func compileIgnoreLine(line string) (ignoreRule, bool, error) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimIgnoreTrailingSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false, nil
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false, nil
+	}
+
+	// 开头或中间含有斜杠的规则相对忽略文件所在目录，否则匹配任意层级
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+
+	re, err := regexp.Compile("^" + ignorePatternToRegexp(line) + "$")
+	if err != nil {
+		return ignoreRule{}, true, err
+	}
+	rule.re = re
+	return rule, true, nil
+}
+
+// trimIgnoreTrailingSpace 去掉未转义的行尾空格
+func trimIgnoreTrailingSpace(line string) string {
+	for strings.HasSuffix(line, " ") {
+		trimmed := line[:len(line)-1]
+		if strings.HasSuffix(trimmed, `\`) {
+			break
+		}
+		line = trimmed
+	}
+	return line
+}
+
+// ignorePatternToRegexp 将 gitignore 通配符转换为正则表达式
+func ignorePatternToRegexp(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**") {
+				atStart := i == 0 || pattern[i-1] == '/'
+				rest := pattern[i+2:]
+				switch {
+				case atStart && strings.HasPrefix(rest, "/"):
+					// "**/" 匹配零或多级目录
+					sb.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				case atStart && rest == "" && i > 0:
+					// 末尾的 "/**" 匹配目录下的所有内容
+					sb.WriteString(".*")
+					i++
+					continue
+				}
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == 0 {
+				// "[]...]" 中第一个 ] 是普通字符
+				if next := strings.IndexByte(pattern[i+2:], ']'); next >= 0 {
+					end = next + 1
+				} else {
+					end = -1
+				}
+			}
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	return sb.String()
+}
+
+// relIgnorePath 返回相对 root 的斜杠路径
+func relIgnorePath(root, p string) string {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return path.Clean(filepath.ToSlash(rel))
+}
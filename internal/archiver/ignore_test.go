@@ -0,0 +1,93 @@
+package archiver
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeTestTree 按斜杠路径创建文件
+func writeTestTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCollectFilesNestedIgnore(t *testing.T) {
+	root := t.TempDir()
+	tree := map[string]string{
+		".gitignore":     "*.log\nbuild/\n",
+		".ignore":        "*.tmp\n",
+		".archiveignore": "!important.tmp\n",
+		"a.log":          "",
+		"keep.txt":       "",
+		"other.tmp":      "",
+		"important.tmp":  "",
+		"build/out.o":    "",
+
+		// 子目录的规则优先：重新包含父目录排除的 c.log，锚定的 /only 只匹配本目录下一级
+		"sub/.gitignore": "!c.log\n/only\n",
+		"sub/c.log":      "",
+		"sub/d.log":      "",
+		"sub/only":       "",
+		"sub/x/only":     "",
+		"sub/deep/c.log": "",
+
+		// 更深的目录再次排除
+		"sub/deeper/.gitignore": "c.log\n",
+		"sub/deeper/c.log":      "",
+
+		// 目录自身的忽略文件排除其中除忽略文件外的全部文件
+		"build2/.gitignore": "*\n!.gitignore\n",
+		"build2/x.log":      "",
+		"build2/y.txt":      "",
+	}
+	writeTestTree(t, root, tree)
+
+	collect := func(respect bool) ([]string, map[string]string) {
+		files, _, skipped, err := collectFiles(CompressOptions{Source: root, RespectIgnoreFiles: respect})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rels []string
+		for _, file := range files {
+			rels = append(rels, relIgnorePath(root, file))
+		}
+		slices.Sort(rels)
+		reasons := make(map[string]string)
+		for _, s := range skipped {
+			reasons[s.Path] = s.Reason
+		}
+		return rels, reasons
+	}
+
+	got, reasons := collect(true)
+	want := []string{
+		".archiveignore", ".gitignore", ".ignore",
+		"build2/.gitignore",
+		"important.tmp", "keep.txt",
+		"sub/.gitignore", "sub/c.log", "sub/deep/c.log", "sub/deeper/.gitignore", "sub/x/only",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("collected %q,\nwant %q", got, want)
+	}
+	for _, path := range []string{"a.log", "other.tmp", "build", "sub/d.log", "sub/only", "sub/deeper/c.log", "build2/x.log", "build2/y.txt"} {
+		if reasons[path] != SkipIgnored {
+			t.Errorf("%s: skip reason %q, want %q", path, reasons[path], SkipIgnored)
+		}
+	}
+
+	// 不读取忽略文件时收集全部文件
+	got, _ = collect(false)
+	if len(got) != len(tree) {
+		t.Fatalf("collected %d files without ignore files, want %d: %q", len(got), len(tree), got)
+	}
+}
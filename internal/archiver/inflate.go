@@ -0,0 +1,444 @@
+package archiver
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 用于建立 Gzip 索引的 Deflate 解码器（RFC 1951）
+// 标准库的解码器不暴露块边界，索引需要知道每个块从压缩流的第几位开始，因此单独实现
+// 只在建立索引时使用一次，按位置读取时仍由标准库从检查点开始解码
+
+const (
+	deflateWindowSize = 32 << 10
+	huffmanMaxBits    = 15
+	huffmanFastBits   = 9
+)
+
+var errInvalidDeflate = errors.New("Deflate 数据已损坏")
+
+// 长度和距离码的基础值与额外位数
+var (
+	deflateLengthBase  = [29]uint16{3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258}
+	deflateLengthExtra = [29]uint8{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	deflateDistBase    = [30]uint16{1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577}
+	deflateDistExtra   = [30]uint8{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+	// 动态块中码长码的排列顺序
+	deflateCodeOrder = [19]uint8{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+)
+
+// bitReader 按 Deflate 的位序（低位在前）读取，并记录当前的位位置
+type bitReader struct {
+	r    *bufio.Reader
+	pos  int64 // 已从 r 读取的字节数
+	bits uint64
+	nb   uint
+}
+
+// refill 尽量填满位缓冲，到达末尾时不报错，由 need 判断位数是否足够
+func (b *bitReader) refill() {
+	for b.nb <= 56 {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			return
+		}
+		b.bits |= uint64(c) << b.nb
+		b.nb += 8
+		b.pos++
+	}
+}
+
+func (b *bitReader) need(n uint) error {
+	if b.nb < n {
+		b.refill()
+		if b.nb < n {
+			return io.ErrUnexpectedEOF
+		}
+	}
+	return nil
+}
+
+func (b *bitReader) read(n uint) (uint32, error) {
+	if err := b.need(n); err != nil {
+		return 0, err
+	}
+	v := uint32(b.bits & (1<<n - 1))
+	b.bits >>= n
+	b.nb -= n
+	return v, nil
+}
+
+// bitPos 下一个未读取的位在流中的位置
+func (b *bitReader) bitPos() int64 {
+	return b.pos*8 - int64(b.nb)
+}
+
+// alignByte 丢弃到下一个字节边界的剩余位
+func (b *bitReader) alignByte() {
+	n := b.nb % 8
+	b.bits >>= n
+	b.nb -= n
+}
+
+// readByte 在字节边界上读取一个字节
+func (b *bitReader) readByte() (byte, error) {
+	v, err := b.read(8)
+	return byte(v), err
+}
+
+// atEOF 字节对齐后是否已没有数据
+func (b *bitReader) atEOF() bool {
+	b.refill()
+	return b.nb == 0
+}
+
+// huffman 规范 Huffman 码的解码表
+type huffman struct {
+	fast   [1 << huffmanFastBits]uint16 // 码长不超过 huffmanFastBits 的码：符号<<4 | 码长
+	count  [huffmanMaxBits + 1]uint16   // 各码长的符号数
+	symbol []uint16                     // 按码排序的符号
+}
+
+// build 由各符号的码长建立解码表，码长为 0 的符号不使用
+func (h *huffman) build(lengths []uint8) error {
+	h.count = [huffmanMaxBits + 1]uint16{}
+	for _, l := range lengths {
+		h.count[l]++
+	}
+	h.count[0] = 0
+	left := 1
+	for l := 1; l <= huffmanMaxBits; l++ {
+		left = left<<1 - int(h.count[l])
+		if left < 0 {
+			return errInvalidDeflate
+		}
+	}
+
+	var offs [huffmanMaxBits + 2]uint16
+	for l := 1; l <= huffmanMaxBits; l++ {
+		offs[l+1] = offs[l] + h.count[l]
+	}
+	h.symbol = make([]uint16, offs[huffmanMaxBits+1])
+	for sym, l := range lengths {
+		if l != 0 {
+			h.symbol[offs[l]] = uint16(sym)
+			offs[l]++
+		}
+	}
+
+	// 短码的快速查找表，以码的位反序为下标（Deflate 的 Huffman 码高位在前）
+	h.fast = [1 << huffmanFastBits]uint16{}
+	code := 0
+	var next [huffmanMaxBits + 1]int
+	for l := 1; l <= huffmanMaxBits; l++ {
+		code = (code + int(h.count[l-1])) << 1
+		next[l] = code
+	}
+	for sym, l := range lengths {
+		if l == 0 || l > huffmanFastBits {
+			if l != 0 {
+				next[l]++
+			}
+			continue
+		}
+		rev := reverseBits(next[l], uint(l))
+		next[l]++
+		for i := rev; i < len(h.fast); i += 1 << l {
+			h.fast[i] = uint16(sym)<<4 | uint16(l)
+		}
+	}
+	return nil
+}
+
+// reverseBits 反转 code 的低 n 位
+func reverseBits(code int, n uint) int {
+	rev := 0
+	for i := uint(0); i < n; i++ {
+		rev = rev<<1 | code&1
+		code >>= 1
+	}
+	return rev
+}
+
+// decode 读取一个符号，短码查表，长码逐位解码
+func (b *bitReader) decode(h *huffman) (int, error) {
+	if b.nb < huffmanFastBits {
+		b.refill()
+	}
+	if b.nb >= huffmanFastBits {
+		if e := h.fast[b.bits&(1<<huffmanFastBits-1)]; e != 0 {
+			n := uint(e & 15)
+			b.bits >>= n
+			b.nb -= n
+			return int(e >> 4), nil
+		}
+	}
+
+	code, first, index := 0, 0, 0
+	for l := 1; l <= huffmanMaxBits; l++ {
+		bit, err := b.read(1)
+		if err != nil {
+			return 0, err
+		}
+		code |= int(bit)
+		count := int(h.count[l])
+		if code-count < first {
+			return int(h.symbol[index+code-first]), nil
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	return 0, errInvalidDeflate
+}
+
+// inflater 解码 Deflate 流，在每个块开始时回调 onBlock
+type inflater struct {
+	br  *bitReader
+	out []byte // 最近的输出，保留至少一个窗口供回溯引用
+	pos int64  // 已解码的总字节数
+
+	flushed int // out 中已交给 emit 的字节数
+	emit    func([]byte) error
+	onBlock func() error
+
+	lit, dist huffman
+}
+
+// window 最近 32KB 的输出
+func (f *inflater) window() []byte {
+	return f.out[max(len(f.out)-deflateWindowSize, 0):]
+}
+
+// flush 输出未交出的数据，只保留一个窗口
+func (f *inflater) flush() error {
+	if err := f.emit(f.out[f.flushed:]); err != nil {
+		return err
+	}
+	if len(f.out) > deflateWindowSize {
+		n := copy(f.out, f.window())
+		f.out = f.out[:n]
+	}
+	f.flushed = len(f.out)
+	return nil
+}
+
+// reset 开始新的 Deflate 流，之前的输出不能再被引用
+func (f *inflater) reset() error {
+	if err := f.flush(); err != nil {
+		return err
+	}
+	f.out, f.flushed = f.out[:0], 0
+	return nil
+}
+
+// inflate 解码一个完整的 Deflate 流，结束后停在字节边界上
+func (f *inflater) inflate() error {
+	for {
+		if err := f.onBlock(); err != nil {
+			return err
+		}
+		header, err := f.br.read(3)
+		if err != nil {
+			return err
+		}
+		switch header >> 1 {
+		case 0:
+			err = f.stored()
+		case 1:
+			err = f.fixed()
+		case 2:
+			err = f.dynamic()
+		default:
+			err = errInvalidDeflate
+		}
+		if err != nil {
+			return err
+		}
+		if len(f.out)-f.flushed >= 4*deflateWindowSize {
+			if err := f.flush(); err != nil {
+				return err
+			}
+		}
+		if header&1 == 1 {
+			f.br.alignByte()
+			return nil
+		}
+	}
+}
+
+// stored 复制未压缩的块
+func (f *inflater) stored() error {
+	f.br.alignByte()
+	v, err := f.br.read(32)
+	if err != nil {
+		return err
+	}
+	n := v & 0xffff
+	if n != ^v>>16 {
+		return errInvalidDeflate
+	}
+	for ; n > 0; n-- {
+		c, err := f.br.readByte()
+		if err != nil {
+			return err
+		}
+		f.out = append(f.out, c)
+	}
+	f.pos += int64(v & 0xffff)
+	return nil
+}
+
+// fixed 使用固定 Huffman 码的块
+func (f *inflater) fixed() error {
+	var lengths [288 + 30]uint8
+	for i := range 288 {
+		switch {
+		case i < 144:
+			lengths[i] = 8
+		case i < 256:
+			lengths[i] = 9
+		case i < 280:
+			lengths[i] = 7
+		default:
+			lengths[i] = 8
+		}
+	}
+	for i := range 30 {
+		lengths[288+i] = 5
+	}
+	if err := f.lit.build(lengths[:288]); err != nil {
+		return err
+	}
+	if err := f.dist.build(lengths[288:]); err != nil {
+		return err
+	}
+	return f.codes()
+}
+
+// dynamic 读取块中的 Huffman 码表后解码
+func (f *inflater) dynamic() error {
+	v, err := f.br.read(14)
+	if err != nil {
+		return err
+	}
+	nlen, ndist, ncode := int(v&31)+257, int(v>>5&31)+1, int(v>>10)+4
+	if nlen > 286 || ndist > 30 {
+		return errInvalidDeflate
+	}
+
+	var lengths [320]uint8
+	for i := range ncode {
+		l, err := f.br.read(3)
+		if err != nil {
+			return err
+		}
+		lengths[deflateCodeOrder[i]] = uint8(l)
+	}
+	var codeLen huffman
+	if err := codeLen.build(lengths[:19]); err != nil {
+		return err
+	}
+
+	lengths = [320]uint8{}
+	for i := 0; i < nlen+ndist; {
+		sym, err := f.br.decode(&codeLen)
+		if err != nil {
+			return err
+		}
+		if sym < 16 {
+			lengths[i] = uint8(sym)
+			i++
+			continue
+		}
+		var repeat uint32
+		var value uint8
+		switch sym {
+		case 16:
+			if i == 0 {
+				return errInvalidDeflate
+			}
+			value = lengths[i-1]
+			repeat, err = f.br.read(2)
+			repeat += 3
+		case 17:
+			repeat, err = f.br.read(3)
+			repeat += 3
+		default:
+			repeat, err = f.br.read(7)
+			repeat += 11
+		}
+		if err != nil {
+			return err
+		}
+		if i+int(repeat) > nlen+ndist {
+			return errInvalidDeflate
+		}
+		for ; repeat > 0; repeat-- {
+			lengths[i] = value
+			i++
+		}
+	}
+	if lengths[256] == 0 {
+		return errInvalidDeflate
+	}
+	if err := f.lit.build(lengths[:nlen]); err != nil {
+		return err
+	}
+	if err := f.dist.build(lengths[nlen : nlen+ndist]); err != nil {
+		return err
+	}
+	return f.codes()
+}
+
+// codes 解码字面量和回溯引用，直到块结束符
+func (f *inflater) codes() error {
+	for {
+		sym, err := f.br.decode(&f.lit)
+		if err != nil {
+			return err
+		}
+		if sym < 256 {
+			f.out = append(f.out, byte(sym))
+			f.pos++
+			continue
+		}
+		if sym == 256 {
+			return nil
+		}
+
+		sym -= 257
+		if sym >= len(deflateLengthBase) {
+			return errInvalidDeflate
+		}
+		extra, err := f.br.read(uint(deflateLengthExtra[sym]))
+		if err != nil {
+			return err
+		}
+		length := int(deflateLengthBase[sym]) + int(extra)
+
+		sym, err = f.br.decode(&f.dist)
+		if err != nil {
+			return err
+		}
+		if sym >= len(deflateDistBase) {
+			return errInvalidDeflate
+		}
+		extra, err = f.br.read(uint(deflateDistExtra[sym]))
+		if err != nil {
+			return err
+		}
+		dist := int(deflateDistBase[sym]) + int(extra)
+		if dist > len(f.out) {
+			return fmt.Errorf("%w: 回溯距离超出已解码的数据", errInvalidDeflate)
+		}
+
+		start := len(f.out) - dist
+		for i := range length {
+			f.out = append(f.out, f.out[start+i])
+		}
+		f.pos += int64(length)
+	}
+}
@@ -0,0 +1,68 @@
+package archiver
+
+import (
+	"compress/flate"
+	"fmt"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// 压缩级别范围，0 表示使用各格式的默认级别
+const (
+	MinLevel = 1
+	MaxLevel = 9
+)
+
+// validateLevel 检查压缩级别
+func validateLevel(level int) error {
+	if level != 0 && (level < MinLevel || level > MaxLevel) {
+		return fmt.Errorf("压缩级别必须在 %d-%d 之间: %d", MinLevel, MaxLevel, level)
+	}
+	return nil
+}
+
+// flateLevel ZIP / Gzip 使用的 Deflate 级别
+func flateLevel(level int) int {
+	if level == 0 {
+		return flate.DefaultCompression
+	}
+	return level
+}
+
+// bzip2Level Bzip2 级别
+func bzip2Level(level int) int {
+	if level == 0 {
+		return bzip2.DefaultCompression
+	}
+	return level
+}
+
+// xzDictCap XZ 没有压缩级别，按 xz -1 ~ -9 预设的字典大小调整
+func xzDictCap(level int) int {
+	dictCaps := [...]int{8 << 20, 1 << 20, 2 << 20, 4 << 20, 4 << 20, 8 << 20, 8 << 20, 16 << 20, 32 << 20, 64 << 20}
+	return dictCaps[level]
+}
+
+// zstdLevel Zstd 级别，按 zstd 命令行的 1-9 级换算
+func zstdLevel(level int) zstd.EncoderLevel {
+	if level == 0 {
+		return zstd.SpeedDefault
+	}
+	return zstd.EncoderLevelFromZstd(level)
+}
+
+// lz4Level LZ4 级别
+func lz4Level(level int) lz4.CompressionLevel {
+	levels := [...]lz4.CompressionLevel{lz4.Fast, lz4.Level1, lz4.Level2, lz4.Level3, lz4.Level4, lz4.Level5, lz4.Level6, lz4.Level7, lz4.Level8, lz4.Level9}
+	return levels[level]
+}
+
+// sevenZipLevel 7z 的 -mx 级别，默认使用最高压缩
+func sevenZipLevel(level int) int {
+	if level == 0 {
+		return 9
+	}
+	return level
+}
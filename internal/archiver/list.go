@@ -0,0 +1,209 @@
+package archiver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/bodgit/sevenzip"
+)
+
+// ArchiveEntry 归档中的一个条目
+type ArchiveEntry struct {
+	Name    string // 归档中的原始路径（ZIP 按 Charset 转换为 UTF-8）
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+	IsDir   bool
+	Link    string // 符号链接或硬链接的目标
+}
+
+// List 列出归档中的条目，不写入任何文件
+// 使用 opts 中的 Source、Password、Charset、Entries 和解码参数；带索引的可寻址 TAR.ZST 和有 Gzip 索引的 TAR.GZ 只读取索引
+func List(ctx context.Context, opts ExtractOptions) ([]ArchiveEntry, error) {
+	if _, err := os.Stat(opts.Source); err != nil {
+		return nil, fmt.Errorf("源文件不存在: %w", err)
+	}
+	format := DetectArchiveFormat(opts.Source)
+	if format == "" {
+		return nil, fmt.Errorf("不支持的归档格式")
+	}
+	if err := ValidateWorkers(opts.Workers); err != nil {
+		return nil, err
+	}
+	if err := ValidateGzipBlockSize(opts.GzipBlockSize); err != nil {
+		return nil, err
+	}
+
+	var entries []ArchiveEntry
+	var err error
+	switch format {
+	case ".zip":
+		entries, err = listZip(opts)
+	case ".7z":
+		entries, err = list7z(opts)
+	default:
+		entries, err = listTar(ctx, format, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	selector := newEntrySelector(opts.Entries)
+	selected := entries[:0]
+	for _, entry := range entries {
+		if selector.match(entry.Name) {
+			selected = append(selected, entry)
+		}
+	}
+	return selected, nil
+}
+
+// listZip 列出 ZIP 条目，中央目录不加密，不需要密码
+func listZip(opts ExtractOptions) ([]ArchiveEntry, error) {
+	reader, err := zip.OpenReader(opts.Source)
+	if err != nil {
+		return nil, fmt.Errorf("打开 ZIP 文件失败: %w", err)
+	}
+	defer reader.Close()
+
+	rawNames := make([]string, len(reader.File))
+	flags := make([]uint16, len(reader.File))
+	for i, file := range reader.File {
+		rawNames[i], flags[i] = file.Name, file.Flags
+	}
+	names, err := decodeZipNames(rawNames, flags, opts.Charset)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ArchiveEntry, len(reader.File))
+	for i, file := range reader.File {
+		entries[i] = fileInfoEntry(names[i], file.FileInfo())
+	}
+	return entries, nil
+}
+
+// list7z 列出 7z 条目，加密了文件头的归档需要密码
+func list7z(opts ExtractOptions) ([]ArchiveEntry, error) {
+	var reader *sevenzip.ReadCloser
+	var err error
+	if opts.Password != "" {
+		reader, err = sevenzip.OpenReaderWithPassword(opts.Source, opts.Password)
+	} else {
+		reader, err = sevenzip.OpenReader(opts.Source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开 7z 文件失败: %w", err)
+	}
+	defer reader.Close()
+
+	entries := make([]ArchiveEntry, len(reader.File))
+	for i, file := range reader.File {
+		entries[i] = fileInfoEntry(file.Name, file.FileInfo())
+	}
+	return entries, nil
+}
+
+// listTar 列出 TAR 条目，可寻址 TAR.ZST 和有 Gzip 索引的 TAR.GZ 只读取索引，其他情况解压并扫描整个流
+func listTar(ctx context.Context, format string, opts ExtractOptions) ([]ArchiveEntry, error) {
+	file, err := os.Open(opts.Source)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	index, err := loadTarIndex(file, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil {
+		var entries []ArchiveEntry
+		for _, entry := range index.Entries {
+			if entry.Type != tar.TypeXGlobalHeader {
+				entries = append(entries, tarHeaderEntry(entry.header()))
+			}
+		}
+		return entries, nil
+	}
+
+	reader, err := openTarStream(file, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var entries []ArchiveEntry
+	tarReader := tar.NewReader(ctxReader{ctx: ctx, r: reader})
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			err = fmt.Errorf("读取 TAR 头失败: %w", err)
+			if format == ".tar.zst" {
+				err = zstdReadError(err, opts)
+			}
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		entries = append(entries, tarHeaderEntry(header))
+	}
+}
+
+// loadTarIndex 读取可寻址 TAR.ZST 内的索引或 TAR.GZ 旁的 Gzip 索引，没有时返回 nil
+func loadTarIndex(file *os.File, format string, opts ExtractOptions) (*tarIndex, error) {
+	switch format {
+	case ".tar.zst":
+		seekable, err := openZstdSeekable(file, opts)
+		if err != nil || seekable == nil {
+			return nil, err
+		}
+		defer seekable.Close()
+		index, err := seekable.tarIndex()
+		if err != nil {
+			return nil, zstdReadError(err, opts)
+		}
+		return index, nil
+	case ".tar.gz":
+		index, err := loadGzipIndex(file, opts.Source)
+		if err != nil || index == nil {
+			return nil, err
+		}
+		return index.Tar, nil
+	default:
+		return nil, nil
+	}
+}
+
+// fileInfoEntry 由 ZIP、7z 条目的 FileInfo 生成列表项
+func fileInfoEntry(name string, info fs.FileInfo) ArchiveEntry {
+	return ArchiveEntry{
+		Name:    name,
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+}
+
+// tarHeaderEntry 由 TAR 头生成列表项
+func tarHeaderEntry(header *tar.Header) ArchiveEntry {
+	info := header.FileInfo()
+	return ArchiveEntry{
+		Name:    header.Name,
+		Size:    header.Size,
+		Mode:    info.Mode(),
+		ModTime: header.ModTime,
+		IsDir:   info.IsDir(),
+		Link:    header.Linkname,
+	}
+}
@@ -0,0 +1,218 @@
+package archiver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 重命名原因
+const (
+	RenameNFC       = "nfc"       // Unicode 规范化为 NFC
+	RenameSanitize  = "sanitize"  // 目标系统不允许的文件名
+	RenameCollision = "collision" // 与其他条目仅大小写不同
+)
+
+// RenamedEntry 解压时被重命名的条目
+type RenamedEntry struct {
+	Original string
+	Renamed  string
+	Reasons  []string // 按处理顺序记录的所有原因，如同时规范化和清理时为 nfc、sanitize
+}
+
+// windowsReservedNames Windows 保留的设备名，带扩展名时同样不可用
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// entryNamer 按 ExtractOptions 规范化条目名称
+// 每一级路径分别处理，目录改名后其下的条目会沿用新名称
+type entryNamer struct {
+	opts     ExtractOptions
+	stats    *ExtractStats
+	rewriter *entryRewriter
+	selector entrySelector
+
+	// 已处理的路径前缀：原始前缀 → 实际前缀
+	prefixes map[string]string
+	// 大小写折叠后的实际路径 → 占用它的原始前缀
+	folded map[string]string
+}
+
+// newEntryNamer 创建条目名称处理器，改写规则已在 Extract 中校验
+func newEntryNamer(opts ExtractOptions, stats *ExtractStats) *entryNamer {
+	rewriter, _ := newEntryRewriter(opts.StripComponents, opts.Rewrite)
+	return &entryNamer{
+		opts:     opts,
+		stats:    stats,
+		rewriter: rewriter,
+		selector: newEntrySelector(opts.Entries),
+		prefixes: make(map[string]string),
+		folded:   make(map[string]string),
+	}
+}
+
+// enabled 是否启用了任何名称处理
+func (n *entryNamer) enabled() bool {
+	return n.opts.NormalizeNFC || n.opts.SanitizeFor != "" || n.opts.DetectCaseCollisions
+}
+
+// resolve 返回条目在磁盘上使用的相对路径
+// 未被 Entries 选中的条目返回 false 表示跳过；选中的条目先应用 StripComponents 和改写规则，结果为空时同样跳过
+func (n *entryNamer) resolve(name string) (string, bool) {
+	if !n.selector.match(name) {
+		return "", false
+	}
+	name, ok := n.rewriter.rewrite(name)
+	if !ok {
+		return "", false
+	}
+	if !n.enabled() {
+		return name, true
+	}
+
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	var original, actual string
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			// 交给后续的路径安全检查处理
+			original = path.Join(original, part)
+			actual = path.Join(actual, part)
+			continue
+		}
+
+		original = path.Join(original, part)
+		if mapped, ok := n.prefixes[original]; ok {
+			actual = mapped
+			continue
+		}
+
+		newPart := part
+		var reasons []string
+		if n.opts.NormalizeNFC {
+			if nfc := norm.NFC.String(newPart); nfc != newPart {
+				newPart = nfc
+				reasons = append(reasons, RenameNFC)
+			}
+		}
+		if n.opts.SanitizeFor != "" {
+			if sanitized := sanitizeName(newPart, n.opts.SanitizeFor); sanitized != newPart {
+				newPart = sanitized
+				reasons = append(reasons, RenameSanitize)
+			}
+		}
+
+		candidate := path.Join(actual, newPart)
+		if n.opts.DetectCaseCollisions {
+			key := foldName(candidate)
+			if owner, taken := n.folded[key]; taken && owner != original {
+				candidate = n.uniqueName(actual, newPart)
+				reasons = append(reasons, RenameCollision)
+			}
+			n.folded[foldName(candidate)] = original
+		}
+
+		n.prefixes[original] = candidate
+		if len(reasons) > 0 {
+			n.stats.Renamed = append(n.stats.Renamed, RenamedEntry{Original: original, Renamed: candidate, Reasons: reasons})
+		}
+		actual = candidate
+	}
+	return actual, true
+}
+
+// entrySelector 按归档中的原始路径选择条目，选中目录时同时选中其下的所有条目
+// 为空时选中全部条目
+type entrySelector []string
+
+// newEntrySelector 规范化要选择的路径
+func newEntrySelector(entries []string) entrySelector {
+	var s entrySelector
+	for _, entry := range entries {
+		s = append(s, cleanEntryName(entry))
+	}
+	return s
+}
+
+// match 条目是否被选中
+func (s entrySelector) match(name string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	name = cleanEntryName(name)
+	for _, entry := range s {
+		if entry == "" || name == entry || strings.HasPrefix(name, entry+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanEntryName 统一条目路径的写法：去掉开头的 ./ 和 /、末尾的 /，Windows 分隔符换成 /
+func cleanEntryName(name string) string {
+	return strings.Trim(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+}
+
+// uniqueName 为冲突的名称生成 "name (2).ext" 形式的新名称
+func (n *entryNamer) uniqueName(dir, name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		candidate := path.Join(dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+		if _, taken := n.folded[foldName(candidate)]; !taken {
+			return candidate
+		}
+	}
+}
+
+// foldName 返回用于大小写不敏感比较的名称
+func foldName(name string) string {
+	return strings.ToLower(norm.NFC.String(name))
+}
+
+// sanitizeName 替换目标系统上不合法的文件名字符
+func sanitizeName(name, targetOS string) string {
+	switch targetOS {
+	case "windows":
+		var sb strings.Builder
+		for _, r := range name {
+			if r < 0x20 || strings.ContainsRune(`<>:"|?*`, r) {
+				sb.WriteRune('_')
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+		name = sb.String()
+
+		// 末尾的点和空格会被 Windows 自动去掉
+		trimmed := strings.TrimRight(name, ". ")
+		if trimmed != name {
+			name = trimmed + strings.Repeat("_", len(name)-len(trimmed))
+		}
+
+		// 保留设备名（CON、NUL.txt 等）
+		stem := name
+		if i := strings.IndexByte(stem, '.'); i >= 0 {
+			stem = stem[:i]
+		}
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+			name = stem + "_" + name[len(stem):]
+		}
+
+	case "darwin":
+		// Finder 将冒号显示为斜杠
+		name = strings.ReplaceAll(name, ":", "_")
+	}
+
+	name = strings.ReplaceAll(name, "\x00", "_")
+	if name == "" {
+		name = "_"
+	}
+	return name
+}
@@ -0,0 +1,209 @@
+package archiver
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 默认的日期、时间格式
+const (
+	defaultDateLayout = "2006-01-02"
+	defaultTimeLayout = "150405"
+)
+
+// maxOutputCounter {counter} 尝试的最大序号
+const maxOutputCounter = 9999
+
+// OutputVars 输出命名模板中可用的变量
+type OutputVars struct {
+	Name      string    // {name} 源文件名（解压时为去掉扩展名的归档名）
+	Ext       string    // {ext} 格式扩展名，如 .tar.gz
+	Time      time.Time // {date}、{date:布局}、{time}、{time:布局}
+	Host      string    // {host} 主机名
+	GitBranch string    // {git.branch} 源目录所在仓库的分支，不在仓库中时为空
+	GitCommit string    // {git.commit} 完整提交哈希，{git.short} 取前 7 位
+}
+
+// NewOutputVars 收集源路径对应的模板变量，git 信息从源路径（文件时为其所在目录）读取
+func NewOutputVars(source, name, ext string, now time.Time) OutputVars {
+	vars := OutputVars{Name: name, Ext: ext, Time: now}
+	vars.Host, _ = os.Hostname()
+
+	dir := source
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		dir = filepath.Dir(source)
+	}
+	vars.GitBranch = gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	vars.GitCommit = gitOutput(dir, "rev-parse", "HEAD")
+	// 分支名中的 / 会被当作目录
+	vars.GitBranch = strings.ReplaceAll(vars.GitBranch, "/", "-")
+	return vars
+}
+
+// gitOutput 在 dir 中执行 git 命令，失败时返回空字符串
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ValidateOutputTemplate 检查模板中的变量是否都能识别
+func ValidateOutputTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("模板不能为空")
+	}
+	_, err := renderTemplate(template, OutputVars{}, 1)
+	return err
+}
+
+// RenderOutputName 按模板生成输出名称，{counter} 按 1 渲染
+func RenderOutputName(template string, vars OutputVars) (string, error) {
+	return renderTemplate(template, vars, 1)
+}
+
+// ResolveOutputPath 按模板生成输出路径，相对路径相对于 dir
+// 模板包含 {counter} 时从 1 开始递增，返回第一个不存在的路径
+func ResolveOutputPath(template, dir string, vars OutputVars) (string, error) {
+	for n := 1; n <= maxOutputCounter; n++ {
+		name, err := renderTemplate(template, vars, n)
+		if err != nil {
+			return "", err
+		}
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if !strings.Contains(template, "{counter") {
+			return path, nil
+		}
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("没有可用的输出序号（已尝试到 %d）: %s", maxOutputCounter, template)
+}
+
+// renderTemplate 替换模板中的 {变量} 和 {变量:参数}
+func renderTemplate(template string, vars OutputVars, counter int) (string, error) {
+	var sb strings.Builder
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("模板中的 { 没有闭合: %s", template)
+		}
+		sb.WriteString(rest[:start])
+
+		name, arg, hasArg := strings.Cut(rest[start+1:start+end], ":")
+		value, err := templateValue(name, arg, hasArg, vars, counter)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(value)
+		rest = rest[start+end+1:]
+	}
+	return sb.String(), nil
+}
+
+// templateValue 返回单个模板变量的值
+func templateValue(name, arg string, hasArg bool, vars OutputVars, counter int) (string, error) {
+	noArg := func(value string) (string, error) {
+		if hasArg {
+			return "", fmt.Errorf("模板变量 {%s} 不接受参数", name)
+		}
+		return value, nil
+	}
+
+	switch name {
+	case "name":
+		return noArg(vars.Name)
+	case "ext":
+		return noArg(vars.Ext)
+	case "host":
+		return noArg(vars.Host)
+	case "git.branch":
+		return noArg(vars.GitBranch)
+	case "git.commit":
+		return noArg(vars.GitCommit)
+	case "git.short":
+		short := vars.GitCommit
+		if len(short) > 7 {
+			short = short[:7]
+		}
+		return noArg(short)
+	case "date", "time":
+		layout := arg
+		if !hasArg {
+			layout = defaultDateLayout
+			if name == "time" {
+				layout = defaultTimeLayout
+			}
+		}
+		if layout == "" {
+			return "", fmt.Errorf("模板变量 {%s:} 缺少时间格式", name)
+		}
+		return vars.Time.Format(layout), nil
+	case "counter":
+		if !hasArg {
+			return strconv.Itoa(counter), nil
+		}
+		width, err := strconv.Atoi(arg)
+		if err != nil || width < 1 || width > 9 {
+			return "", fmt.Errorf("模板变量 {counter:%s} 的位数必须在 1-9 之间", arg)
+		}
+		return fmt.Sprintf("%0*d", width, counter), nil
+	default:
+		return "", fmt.Errorf("未知的模板变量 {%s}，可选 name、ext、date、time、host、git.branch、git.commit、git.short、counter", name)
+	}
+}
+
+// CheckWritable 检查能否在 path 处写入输出，isDir 表示输出是目录（解压）
+// 路径不存在时检查最近的已存在上级目录，通过创建临时文件确认权限
+func CheckWritable(path string, isDir bool) error {
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(path); err == nil {
+		switch {
+		case isDir && !info.IsDir():
+			return fmt.Errorf("目标已存在且不是目录: %s", path)
+		case !isDir && info.IsDir():
+			return fmt.Errorf("目标已存在且是目录: %s", path)
+		case isDir:
+			dir = path
+		}
+	}
+
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s 不是目录", dir)
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if !os.IsNotExist(err) || parent == dir {
+			return fmt.Errorf("无法访问输出目录: %w", err)
+		}
+		dir = parent
+	}
+
+	file, err := os.CreateTemp(dir, ".simplearchiver-*")
+	if err != nil {
+		return fmt.Errorf("输出目录不可写 %s: %w", dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
@@ -0,0 +1,98 @@
+package archiver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resolveSourceDateEpoch 确定可复现模式下的时间戳上限
+// 优先使用 CompressOptions.SourceDateEpoch，其次读取 SOURCE_DATE_EPOCH 环境变量，
+// 两者都没有时返回零值，表示保留文件原始修改时间
+func resolveSourceDateEpoch(opts CompressOptions) (time.Time, error) {
+	if !opts.SourceDateEpoch.IsZero() {
+		return opts.SourceDateEpoch.UTC(), nil
+	}
+
+	value := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH"))
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, fmt.Errorf("SOURCE_DATE_EPOCH 无效: %q", value)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// validateReproducibleOptions 检查可复现模式能否用于所选格式：
+// 7z 由命令行压缩，使用文件的原始时间和自己的条目顺序；加密 ZIP 的每个条目使用随机的盐值
+func validateReproducibleOptions(opts CompressOptions) error {
+	if !opts.Reproducible {
+		return nil
+	}
+	if opts.Format == ".7z" {
+		return fmt.Errorf("可复现模式不支持 7z 格式：7z 命令行使用文件的原始时间和自己的条目顺序")
+	}
+	if opts.Format == ".zip" && opts.Password != "" {
+		return fmt.Errorf("可复现模式不能用于加密 ZIP：AES 加密的每个条目使用随机的盐值")
+	}
+	return nil
+}
+
+// sortFilesForArchive 按 archiveEntryName 得到的条目名称排序，
+// 条目顺序只取决于归档内的路径，与源所在的主机路径和平台无关
+func sortFilesForArchive(files []string, opts CompressOptions) {
+	names := make(map[string]string, len(files))
+	for _, file := range files {
+		names[file] = filepath.ToSlash(archiveEntryName(opts, file))
+	}
+	slices.SortFunc(files, func(a, b string) int {
+		return strings.Compare(names[a], names[b])
+	})
+}
+
+// clampModTime 将修改时间限制在 epoch 之前，epoch 为零值时原样返回
+func clampModTime(t, epoch time.Time) time.Time {
+	if !epoch.IsZero() && t.After(epoch) {
+		t = epoch
+	}
+	return t.UTC().Truncate(time.Second)
+}
+
+// normalizeMode 规范化权限位：目录和可执行文件为 0755，其余为 0644
+func normalizeMode(mode fs.FileMode) fs.FileMode {
+	if mode.IsDir() || mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// normalizeTarHeader 清除 TAR 头中与主机相关的信息
+func normalizeTarHeader(header *tar.Header, epoch time.Time) {
+	header.ModTime = clampModTime(header.ModTime, epoch)
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+	header.Mode = int64(normalizeMode(header.FileInfo().Mode()))
+	header.PAXRecords = nil
+}
+
+// normalizeZipHeader 清除 ZIP 头中与主机相关的信息
+// Modified 使用 UTC，避免 MS-DOS 时间字段随时区变化
+func normalizeZipHeader(header *zip.FileHeader, epoch time.Time) {
+	mode := header.Mode()
+	header.Modified = clampModTime(header.Modified, epoch)
+	header.SetMode(mode&^fs.ModePerm | normalizeMode(mode))
+	header.Extra = nil
+	header.Comment = ""
+}
@@ -0,0 +1,129 @@
+package archiver
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RewriteRule 解压时的条目路径改写规则，类似 tar --transform
+type RewriteRule struct {
+	Match   string // 要替换的路径前缀，Regexp 为 true 时为正则表达式
+	Replace string // 替换内容，正则模式下可用 $1 引用分组
+	Regexp  bool   // Match 是否为正则表达式（替换所有匹配）
+}
+
+// entryRewriter 按 StripComponents 和改写规则处理条目名称
+type entryRewriter struct {
+	strip    int
+	rules    []RewriteRule
+	compiled []*regexp.Regexp
+}
+
+// newEntryRewriter 编译改写规则
+func newEntryRewriter(strip int, rules []RewriteRule) (*entryRewriter, error) {
+	if strip < 0 {
+		return nil, fmt.Errorf("StripComponents 不能为负数: %d", strip)
+	}
+	r := &entryRewriter{strip: strip, rules: rules, compiled: make([]*regexp.Regexp, len(rules))}
+	for i, rule := range rules {
+		if rule.Match == "" {
+			return nil, fmt.Errorf("第 %d 条改写规则缺少匹配内容", i+1)
+		}
+		if !rule.Regexp {
+			continue
+		}
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 条改写规则的正则表达式无效: %w", i+1, err)
+		}
+		r.compiled[i] = re
+	}
+	return r, nil
+}
+
+// enabled 是否需要改写
+func (r *entryRewriter) enabled() bool {
+	return r != nil && (r.strip > 0 || len(r.rules) > 0)
+}
+
+// rewrite 去掉前 strip 级路径并依次应用改写规则，结果为空时返回 false 表示跳过该条目
+func (r *entryRewriter) rewrite(name string) (string, bool) {
+	if !r.enabled() {
+		return name, true
+	}
+
+	if r.strip > 0 {
+		var parts []string
+		for _, part := range strings.Split(name, "/") {
+			if part != "" && part != "." {
+				parts = append(parts, part)
+			}
+		}
+		if len(parts) <= r.strip {
+			return "", false
+		}
+		name = strings.Join(parts[r.strip:], "/")
+	}
+
+	for i, rule := range r.rules {
+		if re := r.compiled[i]; re != nil {
+			name = re.ReplaceAllString(name, rule.Replace)
+		} else if strings.HasPrefix(name, rule.Match) {
+			name = rule.Replace + name[len(rule.Match):]
+		}
+	}
+
+	// 保留 .. 交给后续的路径安全检查处理
+	name = strings.TrimLeft(path.Clean(name), "/")
+	return name, name != "" && name != "."
+}
+
+// validateArchivePrefix 检查压缩时的 ArchivePrefix，必须是不含 .. 的相对路径
+func validateArchivePrefix(prefix string) error {
+	if prefix == "" || prefix == "." {
+		return nil
+	}
+	slashed := filepath.ToSlash(prefix)
+	if path.IsAbs(slashed) || filepath.IsAbs(prefix) {
+		return fmt.Errorf("ArchivePrefix 必须是相对路径: %s", prefix)
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return fmt.Errorf("ArchivePrefix 不能包含 ..: %s", prefix)
+		}
+	}
+	return nil
+}
+
+// archiveEntryName 返回文件在归档中的路径
+// 默认以源目录名为根目录，ArchivePrefix 非空时替换根目录名，为 "." 时条目直接位于归档根部
+// 多个源时每个源保留各自的名称，ArchivePrefix 作为它们共同的上级目录
+func archiveEntryName(opts CompressOptions, file string) string {
+	sources := opts.sourceList()
+	source := sourceOf(sources, file)
+	if len(sources) > 1 {
+		rel, err := filepath.Rel(filepath.Dir(source), file)
+		if err != nil {
+			rel = filepath.Base(file)
+		}
+		return filepath.Join(opts.ArchivePrefix, rel)
+	}
+
+	rel, err := filepath.Rel(source, file)
+	if err != nil {
+		return filepath.Base(file)
+	}
+	root := filepath.Base(source)
+	if opts.ArchivePrefix != "" {
+		root = opts.ArchivePrefix
+	}
+	name := filepath.Join(root, rel)
+	if name == "." {
+		// 单个文件且 ArchivePrefix 为 "."
+		return filepath.Base(file)
+	}
+	return name
+}
@@ -0,0 +1,126 @@
+package archiver
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// ExcludeGroup 待检测的一组排除模式
+type ExcludeGroup struct {
+	Patterns []string
+	// IgnoreFiles 为 true 时按目录中的忽略文件检测，Patterns 不参与匹配
+	IgnoreFiles bool
+}
+
+// ExcludeMatch 一组排除模式在目录中的实际匹配
+type ExcludeMatch struct {
+	Paths []string // 匹配的路径（相对源目录），匹配的目录不再展开
+	Files int      // 排除的文件数，包括匹配目录中的文件
+	Size  int64    // 排除后可节省的字节数
+}
+
+// ScanExcludes 扫描目录，统计每组模式实际匹配的路径和大小
+// 同时返回在未被排除的位置找到的标记文件（如 go.mod、package.json）
+func ScanExcludes(source string, groups []ExcludeGroup, markers []string) ([]ExcludeMatch, map[string]bool, error) {
+	results := make([]ExcludeMatch, len(groups))
+	found := make(map[string]bool)
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.IsDir() {
+		return results, found, nil
+	}
+
+	matchers := make([]*pathMatcher, len(groups))
+	var ignore *ignoreMatcher
+	for i, group := range groups {
+		if group.IgnoreFiles {
+			ignore = newIgnoreMatcher(source)
+			continue
+		}
+		matchers[i] = newPathMatcher(group.Patterns, nil)
+	}
+
+	err = filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 无权限的目录不影响检测
+			if d != nil && d.IsDir() && path != source {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		rel := relIgnorePath(source, path)
+		if path != source {
+			var matched []int
+			for i, group := range groups {
+				if group.IgnoreFiles {
+					if ignore.match(rel, d.IsDir()) {
+						matched = append(matched, i)
+					}
+				} else if matchers[i].excluded(rel, d.IsDir()) {
+					matched = append(matched, i)
+				}
+			}
+
+			if len(matched) > 0 {
+				size, files := entrySize(path, d)
+				for _, i := range matched {
+					results[i].Paths = append(results[i].Paths, rel)
+					results[i].Files += files
+					results[i].Size += size
+				}
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		if d.IsDir() {
+			if ignore != nil {
+				return ignore.loadDir(rel)
+			}
+			return nil
+		}
+		if slices.Contains(markers, d.Name()) {
+			found[d.Name()] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, found, nil
+}
+
+// entrySize 返回文件大小和文件数，目录返回其中所有文件的总大小和数量
+func entrySize(path string, d fs.DirEntry) (int64, int) {
+	if !d.IsDir() {
+		info, err := d.Info()
+		if err != nil {
+			return 0, 1
+		}
+		return info.Size(), 1
+	}
+
+	var size int64
+	files := 0
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			files++
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size, files
+}
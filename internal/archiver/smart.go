@@ -0,0 +1,147 @@
+package archiver
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// extractSmart 智能解压（同 dtrx）：归档只有一个顶层目录时保留该目录的名称，直接解压到 Output 的上级目录，
+// 否则解压到 Output。ZIP、7z 和带索引的 TAR 先读取条目列表再决定，其他 TAR 流只能先解压到临时目录
+func extractSmart(ctx context.Context, format string, opts ExtractOptions, stats *ExtractStats) error {
+	parent := filepath.Dir(opts.Output)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	entries, ok, err := smartEntries(format, opts)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return extractSmartStaged(ctx, format, opts, stats)
+	}
+
+	if top := smartTopDir(entries, opts); top != "" && isEmptyOrMissing(filepath.Join(parent, top)) {
+		// 所有条目都在顶层目录下，解压到上级目录即保留了该目录
+		stats.FlattenedDir = top
+		stats.Output = filepath.Join(parent, top)
+		opts.Output = parent
+		return extractFormat(ctx, format, opts, stats)
+	}
+
+	if err := os.MkdirAll(opts.Output, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	return extractFormat(ctx, format, opts, stats)
+}
+
+// smartEntries 不解压数据读取条目列表，没有中央目录或索引的 TAR 流返回 false
+func smartEntries(format string, opts ExtractOptions) ([]ArchiveEntry, bool, error) {
+	switch format {
+	case ".zip":
+		entries, err := listZip(opts)
+		return entries, err == nil, err
+	case ".7z":
+		entries, err := list7z(opts)
+		return entries, err == nil, err
+	}
+
+	file, err := os.Open(opts.Source)
+	if err != nil {
+		return nil, false, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	index, err := loadTarIndex(file, format, opts)
+	if err != nil || index == nil {
+		return nil, false, err
+	}
+	var entries []ArchiveEntry
+	for _, entry := range index.Entries {
+		if entry.Type != tar.TypeXGlobalHeader {
+			entries = append(entries, tarHeaderEntry(entry.header()))
+		}
+	}
+	return entries, true, nil
+}
+
+// smartTopDir 判断解压后是否只有一个顶层目录，返回它在磁盘上的名称
+// 条目名称按解压时相同的规则（选择、去除层级、改写和规范化）处理
+func smartTopDir(entries []ArchiveEntry, opts ExtractOptions) string {
+	namer := newEntryNamer(opts, &ExtractStats{})
+	top := ""
+	for _, entry := range entries {
+		name, ok := namer.resolve(entry.Name)
+		if !ok {
+			continue
+		}
+		name = strings.TrimPrefix(path.Clean("/"+name), "/")
+		if name == "" {
+			continue
+		}
+		first, _, nested := strings.Cut(name, "/")
+		if (top != "" && first != top) || (!nested && !entry.IsDir) {
+			return ""
+		}
+		top = first
+	}
+	return top
+}
+
+// extractSmartStaged 先解压到输出目录旁的临时目录，再根据顶层条目移动：
+// 只有一个顶层目录时以原名称移到上级目录，否则将临时目录作为输出目录
+func extractSmartStaged(ctx context.Context, format string, opts ExtractOptions, stats *ExtractStats) error {
+	parent := filepath.Dir(opts.Output)
+	staging, err := os.MkdirTemp(parent, "."+filepath.Base(opts.Output)+".partial-")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	stagingOpts := opts
+	stagingOpts.Output = staging
+	if err := extractFormat(ctx, format, stagingOpts, stats); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(staging)
+	if err != nil {
+		return fmt.Errorf("读取解压结果失败: %w", err)
+	}
+	result, target := staging, opts.Output
+	if len(entries) == 1 && entries[0].IsDir() && isEmptyOrMissing(filepath.Join(parent, entries[0].Name())) {
+		result, target = filepath.Join(staging, entries[0].Name()), filepath.Join(parent, entries[0].Name())
+		stats.FlattenedDir = entries[0].Name()
+		stats.Output = target
+	}
+
+	// 目标可能是空目录，先删除再重命名
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	if err := os.Rename(result, target); err != nil {
+		return fmt.Errorf("移动解压结果失败: %w", err)
+	}
+	if result == staging {
+		// 临时目录由 MkdirTemp 创建，权限为 0700
+		return os.Chmod(target, 0755)
+	}
+	return nil
+}
+
+// isEmptyOrMissing 判断目录不存在或为空
+func isEmptyOrMissing(dir string) bool {
+	f, err := os.Open(dir)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	defer f.Close()
+	_, err = f.Readdirnames(1)
+	return err == io.EOF
+}
@@ -0,0 +1,63 @@
+package archiver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sourceList 返回要压缩的源路径，Sources 非空时忽略 Source
+func (opts CompressOptions) sourceList() []string {
+	if len(opts.Sources) > 0 {
+		return opts.Sources
+	}
+	return []string{opts.Source}
+}
+
+// CheckSources 检查多个源路径能否放入同一个归档
+// 每个源都必须存在，在归档中以各自的名称保存，因此名称不能重复，也不能互相包含
+func CheckSources(sources []string) error {
+	if len(sources) == 0 {
+		return fmt.Errorf("没有选择源路径")
+	}
+
+	names := make(map[string]string, len(sources))
+	for _, source := range sources {
+		if _, err := os.Stat(source); err != nil {
+			return fmt.Errorf("源路径不存在: %w", err)
+		}
+
+		name := filepath.Base(source)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("源路径名称冲突: %s 与 %s 在归档中都会保存为 %s", other, source, name)
+		}
+		names[name] = source
+
+		for _, other := range sources {
+			if other != source && isWithin(other, source) {
+				return fmt.Errorf("源路径 %s 已包含在 %s 中", source, other)
+			}
+		}
+	}
+	return nil
+}
+
+// sourceOf 返回 file 所属的源路径
+func sourceOf(sources []string, file string) string {
+	for _, source := range sources {
+		if file == source || isWithin(source, file) {
+			return source
+		}
+	}
+	return sources[0]
+}
+
+// isWithin 判断 path 是否位于目录 dir 之下
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
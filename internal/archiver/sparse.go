@@ -0,0 +1,278 @@
+package archiver
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// tarBlockSize TAR 记录块大小
+const tarBlockSize = 512
+
+// sparseHoleBlock 解压时检测空洞的块大小（与常见文件系统块大小一致）
+const sparseHoleBlock = 4096
+
+// sparseEntry 稀疏文件中的一个数据区段
+type sparseEntry struct {
+	Offset int64
+	Length int64
+}
+
+// isSparseLayout 判断数据区段是否真正包含空洞
+func isSparseLayout(regions []sparseEntry, size int64) bool {
+	if size == 0 {
+		return false
+	}
+	if len(regions) == 1 && regions[0].Offset == 0 && regions[0].Length == size {
+		return false
+	}
+	return true
+}
+
+// writeSparseToTar 以 PAX 1.0 稀疏格式写入文件
+// Go 标准库的 tar.Writer 会丢弃 GNU.sparse.* 记录，因此这里直接写入原始头部块，
+// 写入前先 Flush 让 tar.Writer 补齐上一个条目的填充
+func writeSparseToTar(tw *tar.Writer, w io.Writer, header *tar.Header, file *os.File, regions []sparseEntry) error {
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	realName, realSize := header.Name, header.Size
+
+	// 末尾是空洞时追加一个零长度区段，读取端据此还原文件大小
+	if n := len(regions); n == 0 || regions[n-1].Offset+regions[n-1].Length < realSize {
+		regions = append(regions, sparseEntry{Offset: realSize})
+	}
+
+	// 稀疏映射：区段数量，随后每个区段的偏移和长度，各占一行
+	var sparseMap bytes.Buffer
+	sparseMap.WriteString(strconv.Itoa(len(regions)) + "\n")
+	var dataSize int64
+	for _, r := range regions {
+		sparseMap.WriteString(strconv.FormatInt(r.Offset, 10) + "\n")
+		sparseMap.WriteString(strconv.FormatInt(r.Length, 10) + "\n")
+		dataSize += r.Length
+	}
+	sparseMap.Write(make([]byte, blockPadding(int64(sparseMap.Len()))))
+	entrySize := int64(sparseMap.Len()) + dataSize
+
+	records := map[string]string{
+		"GNU.sparse.major":    "1",
+		"GNU.sparse.minor":    "0",
+		"GNU.sparse.name":     realName,
+		"GNU.sparse.realsize": strconv.FormatInt(realSize, 10),
+	}
+	if !fitsOctal(entrySize, 12) {
+		records["size"] = strconv.FormatInt(entrySize, 10)
+	}
+	if !fitsOctal(int64(header.Uid), 8) {
+		records["uid"] = strconv.Itoa(header.Uid)
+	}
+	if !fitsOctal(int64(header.Gid), 8) {
+		records["gid"] = strconv.Itoa(header.Gid)
+	}
+	if !fitsUstarString(header.Uname, 32) {
+		records["uname"] = header.Uname
+	}
+	if !fitsUstarString(header.Gname, 32) {
+		records["gname"] = header.Gname
+	}
+
+	var paxData bytes.Buffer
+	for _, k := range []string{"GNU.sparse.major", "GNU.sparse.minor", "GNU.sparse.name", "GNU.sparse.realsize", "size", "uid", "gid", "uname", "gname"} {
+		if v, ok := records[k]; ok {
+			paxData.WriteString(formatPAXRecord(k, v))
+		}
+	}
+
+	dir, base := path.Split(realName)
+
+	// PAX 扩展头
+	paxHeader := *header
+	paxHeader.Name = path.Join(dir, "PaxHeaders.0", base)
+	paxHeader.Size = int64(paxData.Len())
+	paxHeader.Mode = 0644
+	if err := writeRawTarHeader(w, &paxHeader, tar.TypeXHeader); err != nil {
+		return err
+	}
+	if err := writePadded(w, paxData.Bytes()); err != nil {
+		return err
+	}
+
+	// 数据条目头，真实名称和大小由 PAX 记录给出
+	dataHeader := *header
+	dataHeader.Name = path.Join(dir, "GNUSparseFile.0", base)
+	dataHeader.Size = entrySize
+	if err := writeRawTarHeader(w, &dataHeader, tar.TypeReg); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(sparseMap.Bytes()); err != nil {
+		return err
+	}
+	for _, r := range regions {
+		if r.Length == 0 {
+			continue
+		}
+		if _, err := io.Copy(w, io.NewSectionReader(file, r.Offset, r.Length)); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(make([]byte, blockPadding(entrySize)))
+	return err
+}
+
+// writeRawTarHeader 写入一个 USTAR 头部块，放不下的字段应已由 PAX 记录覆盖
+func writeRawTarHeader(w io.Writer, header *tar.Header, typeflag byte) error {
+	var block [tarBlockSize]byte
+
+	name, prefix := splitUstarName(header.Name)
+	copy(block[0:100], name)
+	putOctal(block[100:108], int64(header.Mode&07777))
+	putOctal(block[108:116], int64(header.Uid))
+	putOctal(block[116:124], int64(header.Gid))
+	putOctal(block[124:136], header.Size)
+	putOctal(block[136:148], header.ModTime.Unix())
+	block[156] = typeflag
+	copy(block[257:263], "ustar\x00")
+	copy(block[263:265], "00")
+	if fitsUstarString(header.Uname, 32) {
+		copy(block[265:297], header.Uname)
+	}
+	if fitsUstarString(header.Gname, 32) {
+		copy(block[297:329], header.Gname)
+	}
+	copy(block[345:500], prefix)
+
+	// 校验和按校验字段全为空格计算
+	copy(block[148:156], "        ")
+	var sum int64
+	for _, b := range block {
+		sum += int64(b)
+	}
+	copy(block[148:156], fmt.Sprintf("%06o\x00 ", sum))
+
+	_, err := w.Write(block[:])
+	return err
+}
+
+// splitUstarName 将路径拆分为 USTAR 的 name 和 prefix 字段，放不下时截断（真实名称由 PAX 记录保存）
+func splitUstarName(name string) (string, string) {
+	if len(name) <= ustarNameSize {
+		return name, ""
+	}
+	if prefix, suffix, ok := splitUSTARPath(name); ok {
+		return suffix, prefix
+	}
+	return name[len(name)-ustarNameSize:], ""
+}
+
+// putOctal 以 NUL 结尾的八进制格式写入数值字段，溢出时写入 0
+func putOctal(field []byte, v int64) {
+	if !fitsOctal(v, len(field)) {
+		v = 0
+	}
+	s := strconv.FormatInt(v, 8)
+	s = strings.Repeat("0", len(field)-1-len(s)) + s
+	copy(field, s)
+	field[len(field)-1] = 0
+}
+
+// fitsOctal 检查数值能否放入指定宽度的八进制字段
+func fitsOctal(v int64, width int) bool {
+	return v >= 0 && len(strconv.FormatInt(v, 8)) <= width-1
+}
+
+// fitsUstarString 检查字符串能否原样放入 USTAR 字段
+func fitsUstarString(s string, width int) bool {
+	if len(s) > width {
+		return false
+	}
+	return isASCII(s) && !strings.ContainsRune(s, 0)
+}
+
+// formatPAXRecord 格式化一条 PAX 记录，长度字段包含其自身
+func formatPAXRecord(k, v string) string {
+	const padding = 3 // 空格、等号和换行
+	size := len(k) + len(v) + padding
+	size += len(strconv.Itoa(size))
+	record := strconv.Itoa(size) + " " + k + "=" + v + "\n"
+	if len(record) != size {
+		size = len(record)
+		record = strconv.Itoa(size) + " " + k + "=" + v + "\n"
+	}
+	return record
+}
+
+// writePadded 写入数据并补齐到块边界
+func writePadded(w io.Writer, data []byte) error {
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err := w.Write(make([]byte, blockPadding(int64(len(data)))))
+	return err
+}
+
+// blockPadding 计算补齐到块边界所需的字节数
+func blockPadding(n int64) int64 {
+	return -n & (tarBlockSize - 1)
+}
+
+// isSparseTarHeader 判断 TAR 条目是否为稀疏文件
+func isSparseTarHeader(header *tar.Header) bool {
+	if header.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for k := range header.PAXRecords {
+		if strings.HasPrefix(k, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// copySparse 将稀疏条目写入文件，全零块通过 Seek 跳过以重建空洞
+func copySparse(dst *os.File, src io.Reader, size int64) (int64, error) {
+	buf := make([]byte, sparseHoleBlock)
+	var written int64
+	for written < size {
+		n, err := io.ReadFull(src, buf[:min(int64(len(buf)), size-written)])
+		if n > 0 {
+			if isZeroBlock(buf[:n]) {
+				if _, serr := dst.Seek(int64(n), io.SeekCurrent); serr != nil {
+					return written, serr
+				}
+			} else if _, werr := dst.Write(buf[:n]); werr != nil {
+				return written, werr
+			}
+			written += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+
+	// 末尾的空洞需要通过 Truncate 确定文件大小
+	if err := dst.Truncate(written); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// isZeroBlock 检查数据块是否全为零
+func isZeroBlock(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
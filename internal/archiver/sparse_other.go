@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package archiver
+
+import "os"
+
+// sparseDataRegions 当前平台不支持 SEEK_DATA/SEEK_HOLE，整个文件作为一个数据区段
+func sparseDataRegions(file *os.File, size int64) ([]sparseEntry, error) {
+	return []sparseEntry{{Offset: 0, Length: size}}, nil
+}
@@ -0,0 +1,151 @@
+//go:build linux || darwin || freebsd
+
+package archiver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// allocatedSize 文件实际占用的磁盘空间
+func allocatedSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Sys().(*syscall.Stat_t).Blocks * 512
+}
+
+// writeSparseTestFile 生成开头、中间和末尾都有空洞的文件，返回完整内容
+func writeSparseTestFile(t *testing.T, path string) []byte {
+	t.Helper()
+	const size = 8 << 20
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := file.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+
+	want := make([]byte, size)
+	for _, r := range []struct {
+		offset int64
+		data   []byte
+	}{
+		{1 << 20, xzTestData(64 << 10)},
+		{5 << 20, bytes.Repeat([]byte("sparse"), 2000)},
+	} {
+		if _, err := file.WriteAt(r.data, r.offset); err != nil {
+			t.Fatal(err)
+		}
+		copy(want[r.offset:], r.data)
+	}
+	return want
+}
+
+// readTarGz 用标准库读取 TAR.GZ 中唯一的条目，返回条目头、内容和 TAR 流的大小
+func readTarGz(t *testing.T, path string) (*tar.Header, []byte, int) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := tar.NewReader(bytes.NewReader(raw))
+	header, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Fatalf("archive should hold a single entry, got %v", err)
+	}
+	return header, data, len(raw)
+}
+
+func TestCompressSparseFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "disk.img")
+	want := writeSparseTestFile(t, source)
+	if allocatedSize(t, source) >= int64(len(want))/2 {
+		t.Skip("the filesystem of the temp dir does not keep holes")
+	}
+
+	archive := filepath.Join(dir, "sparse.tar.gz")
+	if _, err := Compress(context.Background(), CompressOptions{Source: source, Output: archive, Format: ".tar.gz"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 标准库按 PAX 1.0 稀疏格式还原条目名称、大小和内容，TAR 流中不包含空洞
+	header, data, tarSize := readTarGz(t, archive)
+	if header.Name != "disk.img" || header.Size != int64(len(want)) {
+		t.Fatalf("entry is %q with size %d, want %q with size %d", header.Name, header.Size, "disk.img", len(want))
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatal("archive/tar reads different content")
+	}
+	if tarSize >= len(want)/2 {
+		t.Fatalf("TAR stream is %d bytes, holes should not be stored", tarSize)
+	}
+
+	// 解压后内容相同，空洞没有分配磁盘空间
+	output := filepath.Join(dir, "out")
+	if _, err := Extract(context.Background(), ExtractOptions{Source: archive, Output: output}); err != nil {
+		t.Fatal(err)
+	}
+	extracted := filepath.Join(output, "disk.img")
+	got, err := os.ReadFile(extracted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("extracted file has different content")
+	}
+	if size := allocatedSize(t, extracted); size >= int64(len(want))/2 {
+		t.Fatalf("extracted file allocates %d bytes, holes were filled in", size)
+	}
+}
+
+func TestCompressSparseFileUSTAR(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "disk.img")
+	want := writeSparseTestFile(t, source)
+
+	// USTAR 不能表示稀疏条目，写成完整的普通文件
+	archive := filepath.Join(dir, "ustar.tar.gz")
+	if _, err := Compress(context.Background(), CompressOptions{Source: source, Output: archive, Format: ".tar.gz", TarFormat: tar.FormatUSTAR}); err != nil {
+		t.Fatal(err)
+	}
+	header, data, tarSize := readTarGz(t, archive)
+	if header.Typeflag != tar.TypeReg || len(header.PAXRecords) != 0 || header.Name != "disk.img" {
+		t.Fatalf("entry should be a plain USTAR file, got type %q, PAX records %v, name %q", header.Typeflag, header.PAXRecords, header.Name)
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatal("archive/tar reads different content")
+	}
+	if tarSize < len(want) {
+		t.Fatalf("TAR stream is %d bytes, the whole file should be stored", tarSize)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build linux || darwin || freebsd
+
+package archiver
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// sparseDataRegions 使用 SEEK_DATA/SEEK_HOLE 探测文件中的数据区段
+// 文件系统不支持时（EINVAL、EOPNOTSUPP 等）与其他平台一样把整个文件作为一个数据区段
+func sparseDataRegions(file *os.File, size int64) ([]sparseEntry, error) {
+	regions, err := seekDataRegions(file, size)
+	if err != nil {
+		regions = []sparseEntry{{Offset: 0, Length: size}}
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return regions, nil
+}
+
+// seekDataRegions 依次用 SEEK_DATA/SEEK_HOLE 找出数据区段，ENXIO 表示之后没有数据
+func seekDataRegions(file *os.File, size int64) ([]sparseEntry, error) {
+	var regions []sparseEntry
+	var offset int64
+
+	for offset < size {
+		dataStart, err := file.Seek(offset, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			break // 剩余部分全是空洞
+		}
+		if err != nil {
+			return nil, err
+		}
+		if dataStart >= size {
+			break
+		}
+
+		holeStart, err := file.Seek(dataStart, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, err
+		}
+		if holeStart > size {
+			holeStart = size
+		}
+
+		regions = append(regions, sparseEntry{Offset: dataStart, Length: holeStart - dataStart})
+		offset = holeStart
+	}
+	return regions, nil
+}
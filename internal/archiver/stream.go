@@ -0,0 +1,113 @@
+package archiver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+)
+
+// TAR.GZ 和 TAR.ZST 的流式压缩参数
+const (
+	// MinGzipBlockSize pgzip 的块必须大于其保留的 16KB 尾部
+	MinGzipBlockSize = 16<<10 + 1
+	// MaxGzipBlockSize 块越大占用内存越多，并行时每个协程各占一块
+	MaxGzipBlockSize = 256 << 20
+	// ZstdLongWindowSize 长距离模式的窗口大小，与 zstd --long 默认的 windowLog 27 相同，
+	// zstd 命令行无需额外参数即可解压
+	ZstdLongWindowSize = 128 << 20
+)
+
+// ValidateGzipBlockSize 检查 TAR.GZ 的块大小，0 表示使用默认值
+func ValidateGzipBlockSize(size int) error {
+	if size != 0 && (size < MinGzipBlockSize || size > MaxGzipBlockSize) {
+		return fmt.Errorf("Gzip 块大小必须在 %d-%d 字节之间: %d", MinGzipBlockSize, MaxGzipBlockSize, size)
+	}
+	return nil
+}
+
+// ValidateZstdWindowSize 检查 Zstd 窗口大小，0 表示由压缩级别决定
+func ValidateZstdWindowSize(size int) error {
+	if size == 0 {
+		return nil
+	}
+	if size < zstd.MinWindowSize || size > zstd.MaxWindowSize || size&(size-1) != 0 {
+		return fmt.Errorf("Zstd 窗口大小必须是 %d-%d 字节之间的 2 的幂: %d", zstd.MinWindowSize, zstd.MaxWindowSize, size)
+	}
+	return nil
+}
+
+// validateStreamOptions 检查压缩选项中的 Gzip / Zstd 参数
+func validateStreamOptions(opts CompressOptions) error {
+	if err := ValidateGzipBlockSize(opts.GzipBlockSize); err != nil {
+		return err
+	}
+	return ValidateZstdWindowSize(opts.ZstdWindowSize)
+}
+
+// newGzipWriter 创建 pgzip 写入器，按 GzipBlockSize 分块，最多 Workers 块同时压缩
+// 输出只取决于块大小，与并行数无关
+func newGzipWriter(w io.Writer, opts CompressOptions) (*pgzip.Writer, error) {
+	gzWriter, err := pgzip.NewWriterLevel(w, flateLevel(opts.Level))
+	if err != nil {
+		return nil, err
+	}
+
+	blockSize := opts.GzipBlockSize
+	if blockSize == 0 {
+		blockSize = 1 << 20 // pgzip 默认值
+	}
+	if err := gzWriter.SetConcurrency(blockSize, resolveWorkers(opts.Workers)); err != nil {
+		return nil, err
+	}
+	return gzWriter, nil
+}
+
+// newGzipReader 创建 pgzip 读取器，GzipBlockSize 为预读块的大小
+// Gzip 只能顺序解码，预读的块数按并行数设置，0 使用 pgzip 的默认值
+func newGzipReader(r io.Reader, opts ExtractOptions) (*pgzip.Reader, error) {
+	return pgzip.NewReaderN(r, opts.GzipBlockSize, opts.Workers)
+}
+
+// zstdEncoderOptions Zstd 编码器的级别、并行数和窗口大小
+// 未指定窗口时由级别决定（最大 8MB），长距离模式改用 ZstdLongWindowSize
+func zstdEncoderOptions(opts CompressOptions) []zstd.EOption {
+	options := []zstd.EOption{
+		zstd.WithEncoderLevel(zstdLevel(opts.Level)),
+		zstd.WithEncoderConcurrency(resolveWorkers(opts.Workers)),
+	}
+
+	windowSize := opts.ZstdWindowSize
+	if windowSize == 0 && opts.ZstdLong {
+		windowSize = ZstdLongWindowSize
+	}
+	if windowSize != 0 {
+		options = append(options, zstd.WithWindowSize(windowSize))
+	}
+	return options
+}
+
+// zstdDecoderOptions Zstd 解码器的并行数和内存上限
+func zstdDecoderOptions(opts ExtractOptions) []zstd.DOption {
+	options := []zstd.DOption{zstd.WithDecoderConcurrency(opts.Workers)}
+	if opts.ZstdMaxMemory > 0 {
+		options = append(options, zstd.WithDecoderMaxMemory(opts.ZstdMaxMemory))
+	}
+	return options
+}
+
+// zstdReadError 窗口超过内存上限或缺少字典时给出可操作的提示
+func zstdReadError(err error, opts ExtractOptions) error {
+	if errors.Is(err, zstd.ErrUnknownDictionary) {
+		return fmt.Errorf("归档使用 Zstd 字典压缩，但没有找到字典文件 %s: %w", ZstdDictPath(opts.Source), err)
+	}
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		if opts.ZstdMaxMemory > 0 {
+			return fmt.Errorf("Zstd 窗口超过内存上限 %d 字节，请调大解压内存上限: %w", opts.ZstdMaxMemory, err)
+		}
+		return fmt.Errorf("Zstd 窗口超过解码器允许的大小: %w", err)
+	}
+	return err
+}
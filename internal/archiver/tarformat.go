@@ -0,0 +1,117 @@
+package archiver
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	ustarNameSize   = 100
+	ustarPrefixSize = 155
+	ustarMaxSize    = 1<<33 - 1 // 11 位八进制
+)
+
+// prepareTarHeader 按指定的 TAR 头格式调整条目头
+// 目标格式无法表示的路径会返回明确的错误，而不是交给 tar.Writer 给出晦涩的提示
+func prepareTarHeader(header *tar.Header, format tar.Format) error {
+	// 名称不是合法 UTF-8 时按 POSIX 约定声明为二进制，避免读取端误解码
+	if !utf8.ValidString(header.Name) && format != tar.FormatUSTAR && format != tar.FormatGNU {
+		if header.PAXRecords == nil {
+			header.PAXRecords = make(map[string]string)
+		}
+		header.PAXRecords["hdrcharset"] = "BINARY"
+	}
+
+	if format == tar.FormatUnknown {
+		return nil
+	}
+	header.Format = format
+
+	// 与自动模式保持一致：不记录访问时间和状态变更时间
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	if format != tar.FormatPAX {
+		header.ModTime = header.ModTime.Truncate(time.Second)
+	}
+
+	if format == tar.FormatGNU {
+		// GNU 格式的用户名和组名同样是 32 字节的定长字段，但可以存放非 ASCII 字符
+		if len(header.Uname) > 32 || strings.ContainsRune(header.Uname, 0) {
+			header.Uname = ""
+		}
+		if len(header.Gname) > 32 || strings.ContainsRune(header.Gname, 0) {
+			header.Gname = ""
+		}
+		return nil
+	}
+	if format != tar.FormatUSTAR {
+		return nil
+	}
+
+	if !isASCII(header.Name) {
+		return errors.New("路径包含非 ASCII 字符，USTAR 格式无法存储，请改用 PAX 格式")
+	}
+	if len(header.Name) > ustarNameSize {
+		if _, _, ok := splitUSTARPath(header.Name); !ok {
+			return fmt.Errorf("路径超出 USTAR 格式限制（文件名最长 %d 字节，目录前缀最长 %d 字节），请改用 PAX 格式",
+				ustarNameSize, ustarPrefixSize)
+		}
+	}
+	// 链接目标只有一个 100 字节的字段，不能像路径那样拆出前缀
+	if !isASCII(header.Linkname) {
+		return errors.New("链接目标包含非 ASCII 字符，USTAR 格式无法存储，请改用 PAX 格式")
+	}
+	if len(header.Linkname) > ustarNameSize {
+		return fmt.Errorf("链接目标超出 USTAR 格式限制（最长 %d 字节），请改用 PAX 格式", ustarNameSize)
+	}
+	if header.Size > ustarMaxSize {
+		return errors.New("文件超过 USTAR 格式的 8 GiB 上限，请改用 PAX 格式")
+	}
+
+	// 用户名和组名放不下时只保留数字 ID
+	if !fitsUstarString(header.Uname, 32) {
+		header.Uname = ""
+	}
+	if !fitsUstarString(header.Gname, 32) {
+		header.Gname = ""
+	}
+	return nil
+}
+
+// splitUSTARPath 将长路径拆分为 USTAR 的 prefix 和 name 两部分
+func splitUSTARPath(name string) (prefix, suffix string, ok bool) {
+	length := len(name)
+	if length <= ustarNameSize || !isASCII(name) {
+		return "", "", false
+	} else if length > ustarPrefixSize+1 {
+		length = ustarPrefixSize + 1
+	} else if name[length-1] == '/' {
+		length--
+	}
+
+	i := strings.LastIndex(name[:length], "/")
+	nlen := len(name) - i - 1
+	if i <= 0 || nlen > ustarNameSize || nlen == 0 || i > ustarPrefixSize {
+		return "", "", false
+	}
+	return name[:i], name[i+1:], true
+}
+
+// supportsPAXSparse 判断指定格式能否写入 PAX 稀疏条目
+func supportsPAXSparse(format tar.Format) bool {
+	return format == tar.FormatUnknown || format == tar.FormatPAX
+}
+
+// isASCII 检查字符串是否只包含 ASCII 字符
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package archiver
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// tarIndexEntry 条目在未压缩 TAR 流中的位置和元数据
+type tarIndexEntry struct {
+	Name    string `json:"n"`
+	Offset  int64  `json:"o"` // 条目头（包括 PAX 扩展头）的起始位置
+	Size    int64  `json:"s"`
+	Mode    int64  `json:"m"`
+	ModTime int64  `json:"t"` // Unix 时间
+	Type    byte   `json:"y"`
+	Link    string `json:"l,omitempty"`
+}
+
+// tarIndex TAR 流的条目索引，用于列出条目和直接读取所选条目
+type tarIndex struct {
+	Entries []tarIndexEntry `json:"entries"`
+	End     int64           `json:"end"` // 最后一个条目结束的位置，即结束标记的起始位置
+}
+
+// header 将索引记录还原为 TAR 头，只包含列出条目需要的字段
+func (e tarIndexEntry) header() *tar.Header {
+	return &tar.Header{
+		Name:     e.Name,
+		Size:     e.Size,
+		Mode:     e.Mode,
+		ModTime:  time.Unix(e.ModTime, 0),
+		Typeflag: e.Type,
+		Linkname: e.Link,
+	}
+}
+
+// tarIndexer 解析写入的 TAR 流，记录每个条目的位置
+type tarIndexer struct {
+	pw    *io.PipeWriter
+	done  chan struct{}
+	index tarIndex
+	err   error
+}
+
+// newTarIndexer 创建索引器，写入的数据在另一个协程中解析
+func newTarIndexer() *tarIndexer {
+	pr, pw := io.Pipe()
+	ix := &tarIndexer{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(ix.done)
+		ix.index, ix.err = scanTarIndex(pr)
+		// 解析失败后继续读取剩余数据，避免阻塞写入方
+		io.Copy(io.Discard, pr)
+	}()
+	return ix
+}
+
+func (ix *tarIndexer) Write(p []byte) (int, error) {
+	return ix.pw.Write(p)
+}
+
+// Finish 结束写入并返回索引，可以重复调用
+func (ix *tarIndexer) Finish() (*tarIndex, error) {
+	ix.pw.Close()
+	<-ix.done
+	return &ix.index, ix.err
+}
+
+// countingReader 记录已读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// scanTarIndex 读取整个 TAR 流并记录每个条目头的位置
+// tar.Reader 不会预读，读完一个条目的数据后位置停在数据末尾，按块对齐即为下一个条目头的位置
+func scanTarIndex(r io.Reader) (tarIndex, error) {
+	var index tarIndex
+	cr := &countingReader{r: r}
+	tr := tar.NewReader(cr)
+	for {
+		offset := alignTarBlock(cr.n)
+		header, err := tr.Next()
+		if err == io.EOF {
+			index.End = offset
+			return index, nil
+		}
+		if err != nil {
+			return index, err
+		}
+		index.Entries = append(index.Entries, tarIndexEntry{
+			Name:    header.Name,
+			Offset:  offset,
+			Size:    header.Size,
+			Mode:    header.Mode,
+			ModTime: header.ModTime.Unix(),
+			Type:    header.Typeflag,
+			Link:    header.Linkname,
+		})
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return index, err
+		}
+	}
+}
+
+// alignTarBlock 向上对齐到 TAR 块
+func alignTarBlock(n int64) int64 {
+	return (n + tarBlockSize - 1) / tarBlockSize * tarBlockSize
+}
+
+// marshal 索引编码为 JSON
+func (index *tarIndex) marshal() ([]byte, error) {
+	return json.Marshal(index)
+}
+
+// unmarshalTarIndex 解码 JSON 索引
+func unmarshalTarIndex(data []byte) (*tarIndex, error) {
+	var index tarIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// selection 返回所选条目在 TAR 流中的区段，相邻的区段合并为一段
+func (index *tarIndex) selection(entries []string) [][2]int64 {
+	selector := newEntrySelector(entries)
+	var sections [][2]int64
+	for i, entry := range index.Entries {
+		if !selector.match(entry.Name) {
+			continue
+		}
+		end := index.End
+		if i+1 < len(index.Entries) {
+			end = index.Entries[i+1].Offset
+		}
+		if n := len(sections); n > 0 && sections[n-1][1] == entry.Offset {
+			sections[n-1][1] = end
+		} else {
+			sections = append(sections, [2]int64{entry.Offset, end})
+		}
+	}
+	return sections
+}
+
+// selectedTarStream 拼接所选条目的区段并加上结束标记，得到只包含这些条目的 TAR 流
+// read 返回未压缩 TAR 流中 [start, end) 的内容
+func (index *tarIndex) selectedTarStream(entries []string, read func(start, end int64) io.Reader) io.Reader {
+	var readers []io.Reader
+	for _, section := range index.selection(entries) {
+		readers = append(readers, read(section[0], section[1]))
+	}
+	readers = append(readers, bytes.NewReader(make([]byte, 2*tarBlockSize)))
+	return io.MultiReader(readers...)
+}
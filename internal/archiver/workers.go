@@ -0,0 +1,40 @@
+package archiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// MaxWorkers 单个压缩、解压任务的最大并行数
+const MaxWorkers = 256
+
+// ValidateWorkers 检查并行数，0 表示使用全部 CPU 核
+func ValidateWorkers(workers int) error {
+	if workers < 0 || workers > MaxWorkers {
+		return fmt.Errorf("并行数必须在 1-%d 之间，0 表示使用全部 CPU 核: %d", MaxWorkers, workers)
+	}
+	return nil
+}
+
+// resolveWorkers 返回实际使用的并行数
+func resolveWorkers(workers int) int {
+	if workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return workers
+}
+
+// ctxReader 每次读取前检查 ctx，使大文件的压缩能及时取消
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,375 @@
+package archiver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"os"
+
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// XZ 容器格式，见 https://tukaani.org/xz/xz-file-format.txt
+// 并行压缩时每个块独立编码，块头记录压缩前后的大小，解压时可按索引并行解码
+const (
+	xzHeaderLen     = 12
+	xzFooterLen     = 12
+	xzCheckCRC64    = 0x04
+	xzCheckLen      = 8
+	xzFilterLZMA2   = 0x21
+	xzMinBlockSize  = 1 << 20
+	xzBlockDictMult = 3 // 与 xz -T 相同，块大小为字典大小的 3 倍
+
+	// xzMemoryLimit 并行压缩或解码时所有协程合计的内存上限，类似 xz 的 --memlimit，超出时减少并行数
+	xzMemoryLimit = 1 << 30
+	// xzMaxBlockSize 并行解码接受的最大块，更大的块（或索引损坏）改用顺序解码
+	xzMaxBlockSize = xzMemoryLimit / 2
+	// xzMaxBlockRatio 索引中原始大小与压缩大小之比的上限，全零数据约为 7000
+	xzMaxBlockRatio = 1 << 14
+)
+
+var (
+	xzHeaderMagic = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	xzFooterMagic = []byte{'Y', 'Z'}
+	crc64Table    = crc64.MakeTable(crc64.ECMA)
+)
+
+// xzBlock 编码好的块及其在索引中的记录
+type xzBlock struct {
+	data         []byte // 块头、压缩数据、填充和校验值
+	unpadded     int64  // 不含填充的大小
+	uncompressed int64
+}
+
+// xzBlockWriter 并行编码多块 XZ 流
+type xzBlockWriter struct {
+	w       io.Writer
+	blocks  *blockWriter[xzBlock]
+	records []xzBlock
+	closed  bool
+}
+
+// newXZBlockWriter 写入流头，dictCap 为 LZMA2 字典大小
+func newXZBlockWriter(w io.Writer, dictCap, workers int) (*xzBlockWriter, error) {
+	if _, err := w.Write(xzStreamHeader()); err != nil {
+		return nil, err
+	}
+
+	blockSize := max(dictCap*xzBlockDictMult, xzMinBlockSize)
+	dictCap = min(dictCap, blockSize)
+	// 每个协程约占用正在编码的块、编码结果和编码器的字典与哈希表
+	workers = xzWorkers(workers, 2*int64(blockSize)+2*int64(dictCap))
+	xw := &xzBlockWriter{w: w}
+	xw.blocks = newBlockWriter(blockSize, workers, func(block []byte) (xzBlock, error) {
+		return encodeXZBlock(block, dictCap)
+	}, func(block xzBlock) error {
+		xw.records = append(xw.records, xzBlock{unpadded: block.unpadded, uncompressed: block.uncompressed})
+		_, err := w.Write(block.data)
+		return err
+	})
+	return xw, nil
+}
+
+func (xw *xzBlockWriter) Write(p []byte) (int, error) {
+	return xw.blocks.Write(p)
+}
+
+// Close 写出剩余的块、索引和流尾，重复调用时不再写入
+func (xw *xzBlockWriter) Close() error {
+	if xw.closed {
+		return xw.blocks.Close()
+	}
+	xw.closed = true
+	if err := xw.blocks.Close(); err != nil {
+		return err
+	}
+
+	index := xzIndex(xw.records)
+	if _, err := xw.w.Write(index); err != nil {
+		return err
+	}
+	_, err := xw.w.Write(xzStreamFooter(len(index)))
+	return err
+}
+
+// xzWorkers 按每个协程的内存占用限制并行数，使合计不超过 xzMemoryLimit，至少为 1
+func xzWorkers(workers int, perWorker int64) int {
+	return int(max(1, min(int64(resolveWorkers(workers)), xzMemoryLimit/perWorker)))
+}
+
+// encodeXZBlock 将一个块编码为完整的 XZ 块
+func encodeXZBlock(block []byte, dictCap int) (xzBlock, error) {
+	var compressed bytes.Buffer
+	lw, err := lzma.Writer2Config{DictCap: dictCap}.NewWriter2(&compressed)
+	if err != nil {
+		return xzBlock{}, err
+	}
+	if _, err := lw.Write(block); err != nil {
+		return xzBlock{}, err
+	}
+	if err := lw.Close(); err != nil {
+		return xzBlock{}, err
+	}
+
+	header := xzBlockHeader(int64(compressed.Len()), int64(len(block)), dictCap)
+	unpadded := int64(len(header) + compressed.Len() + xzCheckLen)
+
+	data := make([]byte, 0, unpadded+3)
+	data = append(data, header...)
+	data = append(data, compressed.Bytes()...)
+	data = append(data, make([]byte, xzPadding(int64(len(data))))...)
+	data = binary.LittleEndian.AppendUint64(data, crc64.Checksum(block, crc64Table))
+
+	return xzBlock{data: data, unpadded: unpadded, uncompressed: int64(len(block))}, nil
+}
+
+// xzStreamHeader 流头：魔数、流标志（CRC64 校验）和 CRC32
+func xzStreamHeader() []byte {
+	header := append([]byte{}, xzHeaderMagic...)
+	flags := []byte{0x00, xzCheckCRC64}
+	header = append(header, flags...)
+	return binary.LittleEndian.AppendUint32(header, crc32.ChecksumIEEE(flags))
+}
+
+// xzStreamFooter 流尾：CRC32、索引大小和流标志
+func xzStreamFooter(indexLen int) []byte {
+	body := binary.LittleEndian.AppendUint32(nil, uint32(indexLen/4-1))
+	body = append(body, 0x00, xzCheckCRC64)
+	footer := binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(body))
+	footer = append(footer, body...)
+	return append(footer, xzFooterMagic...)
+}
+
+// xzBlockHeader 块头，包含压缩后大小、原始大小和 LZMA2 过滤器
+func xzBlockHeader(compressed, uncompressed int64, dictCap int) []byte {
+	body := []byte{0x00, 0x40 | 0x80} // 头大小占位；一个过滤器，带压缩和原始大小
+	body = appendVLI(body, uint64(compressed))
+	body = appendVLI(body, uint64(uncompressed))
+	body = append(body, xzFilterLZMA2, 1, lzma2DictByte(dictCap))
+	body = append(body, make([]byte, xzPadding(int64(len(body))))...)
+	body[0] = byte((len(body)+4)/4 - 1)
+	return binary.LittleEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+}
+
+// xzIndex 索引：每个块的未填充大小和原始大小
+func xzIndex(records []xzBlock) []byte {
+	index := []byte{0x00}
+	index = appendVLI(index, uint64(len(records)))
+	for _, r := range records {
+		index = appendVLI(index, uint64(r.unpadded))
+		index = appendVLI(index, uint64(r.uncompressed))
+	}
+	index = append(index, make([]byte, xzPadding(int64(len(index))))...)
+	return binary.LittleEndian.AppendUint32(index, crc32.ChecksumIEEE(index))
+}
+
+// xzPadding 对齐到 4 字节所需的填充
+func xzPadding(n int64) int64 {
+	return (4 - n%4) % 4
+}
+
+// lzma2DictByte LZMA2 字典大小属性：不小于 dictCap 的 2^n 或 3·2^(n-1)
+func lzma2DictByte(dictCap int) byte {
+	for b := byte(0); b < 40; b++ {
+		if lzma2DictSize(b) >= int64(dictCap) {
+			return b
+		}
+	}
+	return 40
+}
+
+// lzma2DictSize 字典大小属性对应的字节数
+func lzma2DictSize(b byte) int64 {
+	return int64(2|b&1) << (b/2 + 11)
+}
+
+// appendVLI 追加 XZ 变长整数
+func appendVLI(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// readVLI 读取 XZ 变长整数
+func readVLI(r io.ByteReader) (uint64, error) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint64(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, errors.New("xz: 变长整数过长")
+}
+
+// xzBlockInfo 从索引中得到的块位置
+type xzBlockInfo struct {
+	offset       int64 // 块在文件中的偏移
+	unpadded     int64
+	uncompressed int64
+}
+
+// readXZIndex 从文件末尾读取索引，只接受包含多个块的单个流
+// 其他情况（多个流、流填充、单个块等）返回 nil，由调用方顺序解码
+func readXZIndex(file *os.File) ([]xzBlockInfo, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < xzHeaderLen+xzFooterLen {
+		return nil, nil
+	}
+
+	footer := make([]byte, xzFooterLen)
+	if _, err := file.ReadAt(footer, size-xzFooterLen); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(footer[10:], xzFooterMagic) || crc32.ChecksumIEEE(footer[4:10]) != binary.LittleEndian.Uint32(footer) {
+		return nil, nil
+	}
+	if footer[9] != xzCheckCRC64 {
+		return nil, nil
+	}
+
+	indexLen := (int64(binary.LittleEndian.Uint32(footer[4:])) + 1) * 4
+	indexStart := size - xzFooterLen - indexLen
+	if indexStart < xzHeaderLen {
+		return nil, nil
+	}
+	index := make([]byte, indexLen)
+	if _, err := file.ReadAt(index, indexStart); err != nil {
+		return nil, err
+	}
+	if index[0] != 0x00 || crc32.ChecksumIEEE(index[:indexLen-4]) != binary.LittleEndian.Uint32(index[indexLen-4:]) {
+		return nil, nil
+	}
+
+	r := bytes.NewReader(index[1 : indexLen-4])
+	count, err := readVLI(r)
+	if err != nil || count < 2 || count > uint64(indexLen) {
+		return nil, nil
+	}
+	blocks := make([]xzBlockInfo, count)
+	offset := int64(xzHeaderLen)
+	for i := range blocks {
+		unpadded, err1 := readVLI(r)
+		uncompressed, err2 := readVLI(r)
+		if err1 != nil || err2 != nil {
+			return nil, nil
+		}
+		// 解码时按索引中的大小分配内存，不可信的大小交给顺序解码器
+		if unpadded == 0 || unpadded > uint64(indexStart) ||
+			uncompressed > xzMaxBlockSize || uncompressed > unpadded*xzMaxBlockRatio {
+			return nil, nil
+		}
+		blocks[i] = xzBlockInfo{offset: offset, unpadded: int64(unpadded), uncompressed: int64(uncompressed)}
+		offset += int64(unpadded) + xzPadding(int64(unpadded))
+	}
+
+	// 块必须正好填满流头和索引之间的空间，否则是多个流或带有流填充
+	if offset != indexStart {
+		return nil, nil
+	}
+	header := make([]byte, xzHeaderLen)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header, xzStreamHeader()) {
+		return nil, nil
+	}
+	return blocks, nil
+}
+
+// decodeXZBlock 解码单个块并校验大小和 CRC64
+func decodeXZBlock(file *os.File, block xzBlockInfo) ([]byte, error) {
+	raw := make([]byte, block.unpadded+xzPadding(block.unpadded))
+	if _, err := file.ReadAt(raw, block.offset); err != nil {
+		return nil, err
+	}
+
+	headerLen := (int(raw[0]) + 1) * 4
+	if headerLen+xzCheckLen > len(raw) || crc32.ChecksumIEEE(raw[:headerLen-4]) != binary.LittleEndian.Uint32(raw[headerLen-4:headerLen]) {
+		return nil, errors.New("xz: 块头损坏")
+	}
+	r := bytes.NewReader(raw[2 : headerLen-4])
+	var compressed, uncompressed uint64
+	var err error
+	if raw[1]&0x40 != 0 {
+		if compressed, err = readVLI(r); err != nil {
+			return nil, err
+		}
+	}
+	if raw[1]&0x80 != 0 {
+		if uncompressed, err = readVLI(r); err != nil {
+			return nil, err
+		}
+		if uncompressed != uint64(block.uncompressed) {
+			return nil, errors.New("xz: 块头与索引中的大小不一致")
+		}
+	}
+	filter, _ := readVLI(r)
+	propsLen, _ := readVLI(r)
+	props, _ := r.ReadByte()
+	if raw[1]&0x03 != 0 || filter != xzFilterLZMA2 || propsLen != 1 || props > 40 {
+		return nil, errors.New("xz: 不支持的过滤器")
+	}
+
+	dataEnd := int64(len(raw)) - xzCheckLen
+	if compressed != 0 {
+		if compressed > uint64(dataEnd-int64(headerLen)) {
+			return nil, errors.New("xz: 块头中的压缩大小超出块的范围")
+		}
+		dataEnd = int64(headerLen) + int64(compressed)
+	}
+	lr, err := lzma.Reader2Config{DictCap: int(lzma2DictSize(props))}.NewReader2(bytes.NewReader(raw[headerLen:dataEnd]))
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, block.uncompressed)
+	if _, err := io.ReadFull(lr, data); err != nil {
+		return nil, fmt.Errorf("xz: 解码块失败: %w", err)
+	}
+	if crc64.Checksum(data, crc64Table) != binary.LittleEndian.Uint64(raw[len(raw)-xzCheckLen:]) {
+		return nil, errors.New("xz: 块校验失败")
+	}
+	return data, nil
+}
+
+// newXZReader 打开 XZ 文件：单个流中有多个块时并行解码，否则使用顺序读取器
+// 并行数按最大的块受 xzMemoryLimit 限制，每个协程约占用两个解码后的块和一个压缩块
+func newXZReader(file *os.File, workers int) (io.ReadCloser, error) {
+	blocks, err := readXZIndex(file)
+	if err != nil {
+		return nil, err
+	}
+	if blocks != nil {
+		var perWorker int64
+		for _, block := range blocks {
+			perWorker = max(perWorker, 2*block.uncompressed+block.unpadded)
+		}
+		workers = xzWorkers(workers, perWorker)
+	}
+	if blocks == nil || workers == 1 {
+		r, err := xz.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(r), nil
+	}
+
+	return newOrderedReader(len(blocks), workers, func(i int) ([]byte, error) {
+		return decodeXZBlock(file, blocks[i])
+	}, nil), nil
+}
@@ -0,0 +1,239 @@
+package archiver
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+// xzTestData 压缩率不同的文本和随机数据交替
+func xzTestData(size int) []byte {
+	rng := rand.New(rand.NewSource(int64(size)))
+	words := []string{"archive ", "block ", "stream ", "index ", "footer ", "header ", "lzma2 ", "\n"}
+	var buf bytes.Buffer
+	for buf.Len() < size {
+		if rng.Intn(4) == 0 {
+			chunk := make([]byte, rng.Intn(4096))
+			rng.Read(chunk)
+			buf.Write(chunk)
+			continue
+		}
+		for range rng.Intn(512) {
+			buf.WriteString(words[rng.Intn(len(words))])
+		}
+	}
+	return buf.Bytes()[:size]
+}
+
+// writeTestXZ 用 xzBlockWriter 压缩 data 并写入 dir 中的文件
+func writeTestXZ(t *testing.T, dir string, data []byte, dictCap, workers int) string {
+	t.Helper()
+	var buf bytes.Buffer
+	xw, err := newXZBlockWriter(&buf, dictCap, workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 分多次写入，跨越块的边界
+	for chunk := range slices.Chunk(data, 100_000) {
+		if _, err := xw.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := xw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test.xz")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestXZStreamHeader(t *testing.T) {
+	// CRC64 校验的标准流头，与 xz 生成的相同
+	want := []byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00, 0x04, 0xe6, 0xd6, 0xb4, 0x46}
+	if got := xzStreamHeader(); !bytes.Equal(got, want) {
+		t.Fatalf("xzStreamHeader = % x, want % x", got, want)
+	}
+}
+
+func TestXZBlockWriterRoundTrip(t *testing.T) {
+	const dictCap = 1 << 18 // 块大小为 xzMinBlockSize
+	for _, tc := range []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"small", 1000},
+		{"one-block", xzMinBlockSize},
+		{"blocks", 3*xzMinBlockSize + 12345},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := xzTestData(tc.size)
+			path := writeTestXZ(t, t.TempDir(), data, dictCap, 4)
+			compressed, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 参考实现校验流头、块头、索引、流尾和各项 CRC
+			r, err := xz.NewReader(bytes.NewReader(compressed))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("xz.NewReader: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("xz.NewReader output differs from the input")
+			}
+
+			// 并行解码
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			pr, err := newXZReader(file, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pr.Close()
+			got, err = io.ReadAll(pr)
+			if err != nil {
+				t.Fatalf("newXZReader: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("newXZReader output differs from the input")
+			}
+
+			if xzPath, err := exec.LookPath("xz"); err == nil {
+				if out, err := exec.Command(xzPath, "-t", path).CombinedOutput(); err != nil {
+					t.Fatalf("xz -t: %v\n%s", err, out)
+				}
+			}
+		})
+	}
+}
+
+func TestReadXZIndex(t *testing.T) {
+	data := xzTestData(3*xzMinBlockSize + 100)
+	path := writeTestXZ(t, t.TempDir(), data, 1<<18, 2)
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	blocks, err := readXZIndex(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 4 {
+		t.Fatalf("readXZIndex returned %d blocks, want 4", len(blocks))
+	}
+	var total int64
+	for i, block := range blocks {
+		decoded, err := decodeXZBlock(file, block)
+		if err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+		if !bytes.Equal(decoded, data[total:total+block.uncompressed]) {
+			t.Fatalf("block %d has wrong content", i)
+		}
+		total += block.uncompressed
+	}
+	if total != int64(len(data)) {
+		t.Fatalf("index sizes add up to %d, want %d", total, len(data))
+	}
+}
+
+func TestReadXZIndexImplausible(t *testing.T) {
+	dir := t.TempDir()
+	data := xzTestData(2*xzMinBlockSize + 100)
+	path := writeTestXZ(t, dir, data, 1<<18, 2)
+	compressed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := readXZIndex(file)
+	file.Close()
+	if err != nil || len(blocks) < 2 {
+		t.Fatalf("readXZIndex = %d blocks, %v", len(blocks), err)
+	}
+	last := blocks[len(blocks)-1]
+	indexStart := last.offset + last.unpadded + xzPadding(last.unpadded)
+
+	for _, tc := range []struct {
+		name         string
+		uncompressed int64
+	}{
+		{"too-large", xzMaxBlockSize + 1},
+		{"ratio", blocks[0].unpadded*xzMaxBlockRatio + 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// 改写索引中第一个块的原始大小，重新计算索引和流尾的 CRC
+			records := make([]xzBlock, len(blocks))
+			for i, block := range blocks {
+				records[i] = xzBlock{unpadded: block.unpadded, uncompressed: block.uncompressed}
+			}
+			records[0].uncompressed = tc.uncompressed
+			index := xzIndex(records)
+			forged := append(bytes.Clone(compressed[:indexStart]), index...)
+			forged = append(forged, xzStreamFooter(len(index))...)
+			forgedPath := filepath.Join(dir, tc.name+".xz")
+			if err := os.WriteFile(forgedPath, forged, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := os.Open(forgedPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			blocks, err := readXZIndex(file)
+			if err != nil || blocks != nil {
+				t.Fatalf("implausible index should be rejected, got %d blocks, %v", len(blocks), err)
+			}
+
+			// 回退到顺序解码器，索引与块不一致时报错而不是按索引分配内存
+			r, err := newXZReader(file, 4)
+			if err == nil {
+				_, err = io.Copy(io.Discard, r)
+				r.Close()
+			}
+			if err == nil {
+				t.Fatal("decoding a stream with a forged index should fail")
+			}
+		})
+	}
+}
+
+func TestXZWorkers(t *testing.T) {
+	for _, tc := range []struct {
+		workers   int
+		perWorker int64
+		want      int
+	}{
+		{8, 64 << 20, 8},
+		{8, 512 << 20, 2},
+		{8, 2 << 30, 1},
+		{1, 1 << 20, 1},
+	} {
+		if got := xzWorkers(tc.workers, tc.perWorker); got != tc.want {
+			t.Errorf("xzWorkers(%d, %d) = %d, want %d", tc.workers, tc.perWorker, got, tc.want)
+		}
+	}
+}
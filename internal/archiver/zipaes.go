@@ -0,0 +1,120 @@
+package archiver
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// WinZip AES 加密（AE-2）参数，格式见 https://www.winzip.com/en/support/aes-encryption/
+// yeka/zip 只能在 Writer 内部边压缩边加密，并行压缩时需要在工作协程中自行加密
+const (
+	zipMethodAES      = 99     // 加密条目的压缩方法字段
+	zipExtraAES       = 0x9901 // AES 扩展字段 ID
+	zipAESStrength256 = 3
+	zipAESKeyLen      = 32
+	zipAESSaltLen     = 16
+	zipAESAuthLen     = 10
+	zipAESIterations  = 1000
+)
+
+// zipAESWriter 按 WinZip AES-256 加密数据：先写入盐和密码校验值，
+// 数据使用小端计数器的 CTR 模式加密，Close 时写入 HMAC-SHA1 认证码
+type zipAESWriter struct {
+	w      io.Writer
+	stream cipher.Stream
+	mac    hash.Hash
+	buf    []byte
+}
+
+// newZipAESWriter 创建加密写入器并写入盐和密码校验值
+func newZipAESWriter(w io.Writer, password string) (*zipAESWriter, error) {
+	salt := make([]byte, zipAESSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("生成加密盐失败: %w", err)
+	}
+
+	keys, err := pbkdf2.Key(sha1.New, password, salt, zipAESIterations, 2*zipAESKeyLen+2)
+	if err != nil {
+		return nil, fmt.Errorf("生成加密密钥失败: %w", err)
+	}
+	block, err := aes.NewCipher(keys[:zipAESKeyLen])
+	if err != nil {
+		return nil, fmt.Errorf("生成加密密钥失败: %w", err)
+	}
+
+	if _, err := w.Write(salt); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(keys[2*zipAESKeyLen:]); err != nil {
+		return nil, err
+	}
+
+	return &zipAESWriter{
+		w:      w,
+		stream: &winZipCTR{block: block, pos: aes.BlockSize},
+		mac:    hmac.New(sha1.New, keys[zipAESKeyLen:2*zipAESKeyLen]),
+	}, nil
+}
+
+func (w *zipAESWriter) Write(p []byte) (int, error) {
+	if cap(w.buf) < len(p) {
+		w.buf = make([]byte, len(p))
+	}
+	buf := w.buf[:len(p)]
+	w.stream.XORKeyStream(buf, p)
+	w.mac.Write(buf)
+	return w.w.Write(buf)
+}
+
+// Close 写入认证码，不关闭底层写入器
+func (w *zipAESWriter) Close() error {
+	_, err := w.w.Write(w.mac.Sum(nil)[:zipAESAuthLen])
+	return err
+}
+
+// winZipCTR WinZip 使用的 CTR 模式：计数器从 1 开始，按小端递增
+type winZipCTR struct {
+	block   cipher.Block
+	counter [aes.BlockSize]byte
+	key     [aes.BlockSize]byte
+	pos     int
+}
+
+func (c *winZipCTR) XORKeyStream(dst, src []byte) {
+	for len(src) > 0 {
+		if c.pos == aes.BlockSize {
+			for i := range c.counter {
+				c.counter[i]++
+				if c.counter[i] != 0 {
+					break
+				}
+			}
+			c.block.Encrypt(c.key[:], c.counter[:])
+			c.pos = 0
+		}
+		n := subtle.XORBytes(dst, src, c.key[c.pos:])
+		c.pos += n
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// zipAESExtra AE-2 扩展字段，method 为实际的压缩方法
+func zipAESExtra(method uint16) []byte {
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra[0:], zipExtraAES)
+	binary.LittleEndian.PutUint16(extra[2:], 7)      // 数据长度
+	binary.LittleEndian.PutUint16(extra[4:], 2)      // AE-2，不写入 CRC
+	binary.LittleEndian.PutUint16(extra[6:], 0x4541) // "AE"
+	extra[8] = zipAESStrength256
+	binary.LittleEndian.PutUint16(extra[9:], method)
+	return extra
+}
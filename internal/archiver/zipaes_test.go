@@ -0,0 +1,103 @@
+package archiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	yekazip "github.com/yeka/zip"
+)
+
+func TestCompressZipAES(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "src")
+	rng := rand.New(rand.NewSource(1))
+
+	// 空文件、不足一个 AES 块、可压缩文本和随机数据（计数器的低字节会多次进位）
+	random := make([]byte, 300<<10)
+	rng.Read(random)
+	want := map[string][]byte{
+		"empty.txt":       nil,
+		"short.txt":       []byte("hello"),
+		"text/words.txt":  []byte(strings.Repeat("winzip aes counter mode\n", 20000)),
+		"data/random.bin": random,
+	}
+	for i := range 32 {
+		want[fmt.Sprintf("many/%02d.txt", i)] = xzTestData(1000 + i*997)
+	}
+	for name, data := range want {
+		path := filepath.Join(source, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const password = "correct horse"
+	archive := filepath.Join(dir, "secret.zip")
+	if _, err := Compress(context.Background(), CompressOptions{Source: source, Output: archive, Format: ".zip", Password: password, Workers: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := yekazip.OpenReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	// 用参考实现按正确的密码解密每个条目，同时校验认证码
+	found := 0
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		name := strings.TrimPrefix(file.Name, "src/")
+		data, ok := want[name]
+		if !ok {
+			t.Fatalf("unexpected entry %q", file.Name)
+		}
+		if !file.IsEncrypted() {
+			t.Fatalf("%s is not encrypted", name)
+		}
+		file.SetPassword(password)
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(got) != string(data) {
+			t.Fatalf("%s decrypts to different content", name)
+		}
+		found++
+	}
+	if found != len(want) {
+		t.Fatalf("archive has %d files, want %d", found, len(want))
+	}
+
+	// 错误的密码在校验值或认证码处失败
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || file.UncompressedSize64 == 0 {
+			continue
+		}
+		file.SetPassword("wrong password")
+		r, err := file.Open()
+		if err == nil {
+			_, err = io.ReadAll(r)
+			r.Close()
+		}
+		if err == nil {
+			t.Fatalf("%s: reading with a wrong password should fail", file.Name)
+		}
+	}
+}
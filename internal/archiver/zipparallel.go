@@ -0,0 +1,293 @@
+package archiver
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// zipSpillSize 压缩后的条目超过此大小时改存到临时文件，避免大文件占用内存
+const zipSpillSize = 4 << 20
+
+// zipExtraExtTime Info-ZIP 扩展时间戳字段 ID，与 zip.Writer.CreateHeader 写入的一致
+const zipExtraExtTime = 0x5455
+
+// zipEntry 已在工作协程中压缩（和加密）好的条目，按顺序用 CreateRaw 写入
+type zipEntry struct {
+	relPath string
+	header  *zip.FileHeader
+	data    *spillBuffer
+}
+
+// zipEntryResult 工作协程的结果
+type zipEntryResult struct {
+	entry *zipEntry
+	err   error
+}
+
+// compressZipEntries 用 opts.Workers 个协程并行压缩文件，再按 files 的顺序依次调用 write
+// 同时在内存或临时文件中等待写入的条目不超过并行数的两倍；write 或某个条目出错时停止其余工作
+// 条目内容只取决于文件本身，因此输出与并行数无关
+func compressZipEntries(ctx context.Context, files []string, opts CompressOptions, write func(i int, entry *zipEntry) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := min(resolveWorkers(opts.Workers), len(files))
+	window := make(chan struct{}, workers*2)
+	results := make([]chan zipEntryResult, len(files))
+	for i := range results {
+		results[i] = make(chan zipEntryResult, 1)
+	}
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range files {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				entry, err := newZipEntry(ctx, files[i], archiveEntryName(opts, files[i]), opts)
+				results[i] <- zipEntryResult{entry, err}
+			}
+		}()
+	}
+
+	// 退出前等待工作协程结束，并清理已压缩但未写入的条目
+	defer func() {
+		cancel()
+		wg.Wait()
+		for _, result := range results {
+			select {
+			case r := <-result:
+				if r.entry != nil {
+					r.entry.data.Close()
+				}
+			default:
+			}
+		}
+	}()
+
+	for i := range files {
+		var r zipEntryResult
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			return r.err
+		}
+
+		err := write(i, r.entry)
+		r.entry.data.Close()
+		if err != nil {
+			return err
+		}
+		<-window
+	}
+	return nil
+}
+
+// newZipEntry 读取并压缩单个文件，设置密码时同时加密
+func newZipEntry(ctx context.Context, filePath, relPath string, opts CompressOptions) (*zipEntry, error) {
+	entry, err := buildZipEntry(ctx, filePath, relPath, opts)
+	if err != nil {
+		if opts.Password != "" {
+			return nil, fmt.Errorf("添加加密文件失败 %s: %w", relPath, err)
+		}
+		return nil, fmt.Errorf("添加文件失败 %s: %w", relPath, err)
+	}
+	return entry, nil
+}
+
+// buildZipEntry 生成条目头并将压缩后的数据写入 spillBuffer
+// 保留的符号链接按 Info-ZIP 的约定以链接目标作为条目内容
+func buildZipEntry(ctx context.Context, filePath, relPath string, opts CompressOptions) (*zipEntry, error) {
+	var info fs.FileInfo
+	var src io.Reader
+	if keepSymlinks(opts) {
+		linkInfo, target, err := lstatSymlink(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if linkInfo != nil {
+			info, src = linkInfo, strings.NewReader(target)
+		}
+	}
+	if src == nil {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		info, err = file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		src = file
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, err
+	}
+
+	header.Name = filepath.ToSlash(relPath)
+	header.Method = zip.Deflate
+	if utf8.ValidString(header.Name) {
+		header.Flags |= zipFlagUTF8 // 始终标记 UTF-8，避免其他工具按本地编码解读
+	}
+	if opts.Reproducible {
+		normalizeZipHeader(header, opts.SourceDateEpoch)
+	}
+	prepareRawZipHeader(header)
+
+	data := &spillBuffer{dir: filepath.Dir(opts.Output)}
+	var dst io.Writer = data
+	var aes *zipAESWriter
+	if opts.Password != "" {
+		aes, err = newZipAESWriter(data, opts.Password)
+		if err != nil {
+			data.Close()
+			return nil, err
+		}
+		dst = aes
+	}
+
+	fw, err := flate.NewWriter(dst, flateLevel(opts.Level))
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	crc := crc32.NewIEEE()
+	size, err := io.Copy(io.MultiWriter(fw, crc), ctxReader{ctx, src})
+	if err == nil {
+		err = fw.Close()
+	}
+	if err == nil && aes != nil {
+		err = aes.Close()
+	}
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+
+	header.UncompressedSize64 = uint64(size)
+	header.CompressedSize64 = uint64(data.size)
+	header.CRC32 = crc.Sum32()
+	if aes != nil {
+		header.CRC32 = 0 // AE-2 不写入 CRC，由认证码校验数据
+		header.Flags |= 0x1
+		header.Extra = append(header.Extra, zipAESExtra(header.Method)...)
+		header.Method = zipMethodAES
+	}
+
+	return &zipEntry{relPath: relPath, header: header, data: data}, nil
+}
+
+// prepareRawZipHeader 补齐 CreateHeader 会自动设置、而 CreateRaw 不会设置的字段：
+// 版本号、MS-DOS 修改时间和扩展时间戳，使条目与原先逐个写入时一致
+func prepareRawZipHeader(header *zip.FileHeader) {
+	header.CreatorVersion = header.CreatorVersion&0xff00 | 20
+	header.ReaderVersion = 20
+	if header.Modified.IsZero() {
+		return
+	}
+
+	header.ModifiedDate, header.ModifiedTime = msDosTime(header.Modified)
+	extra := make([]byte, 9)
+	binary.LittleEndian.PutUint16(extra[0:], zipExtraExtTime)
+	binary.LittleEndian.PutUint16(extra[2:], 5)
+	extra[4] = 1 // 只包含修改时间
+	binary.LittleEndian.PutUint32(extra[5:], uint32(header.Modified.Unix()))
+	header.Extra = append(header.Extra, extra...)
+}
+
+// msDosTime 将时间转换为 MS-DOS 日期和时间（精度 2 秒）
+func msDosTime(t time.Time) (date, dosTime uint16) {
+	date = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	dosTime = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, dosTime
+}
+
+// spillBuffer 先写入内存，超过 zipSpillSize 后转存到 dir 中的临时文件
+// 临时文件放在输出目录而不是系统临时目录，避免大文件占满内存盘
+type spillBuffer struct {
+	dir  string
+	mem  bytes.Buffer
+	file *os.File
+	size int64
+}
+
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.mem.Len()+len(p) > zipSpillSize {
+		file, err := os.CreateTemp(b.dir, ".simplearchiver-*")
+		if err != nil {
+			return 0, fmt.Errorf("创建临时文件失败: %w", err)
+		}
+		b.file = file
+		if _, err := b.file.Write(b.mem.Bytes()); err != nil {
+			return 0, err
+		}
+		b.mem = bytes.Buffer{}
+	}
+
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// WriteTo 将缓存的数据写入 w
+func (b *spillBuffer) WriteTo(w io.Writer) (int64, error) {
+	if b.file == nil {
+		return b.mem.WriteTo(w)
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, b.file)
+}
+
+// Close 删除临时文件
+func (b *spillBuffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	b.file.Close()
+	return os.Remove(b.file.Name())
+}
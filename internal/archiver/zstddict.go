@@ -0,0 +1,309 @@
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Zstd 字典的存放方式
+const (
+	ZstdDictEmbed   = "embed"   // 写入归档开头的可跳过帧，解压时自动读取
+	ZstdDictSidecar = "sidecar" // 写入归档旁的 .dict 文件，也可以用 zstd -D 解压
+)
+
+// ZstdDictModes 可选的字典存放方式
+var ZstdDictModes = []string{ZstdDictEmbed, ZstdDictSidecar}
+
+const (
+	zstdDictMaxSamples = 1000      // 训练耗时主要取决于样本数，1000 个样本约需数秒
+	zstdDictMinSamples = 8         // 样本太少时训练出的字典没有意义
+	zstdDictSampleSize = 16 << 10  // 字典只对文件开头的内容有效，样本截取前 16KB
+	zstdDictMaxSize    = 112 << 10 // 与 zstd --train 的默认大小相同
+	// zstdDictFrameMagic 本程序写入的可跳过帧的魔数（0x184D2A50-0x184D2A5F），zstd -d 会忽略整个帧
+	zstdDictFrameMagic = 0x184D2A5D
+)
+
+// zstdDictTag 可跳过帧内容的开头，区分其他程序写入的可跳过帧
+var zstdDictTag = []byte("SAZD")
+
+// DictStats Zstd 字典的训练结果，以及与不使用字典时的压缩大小对比
+type DictStats struct {
+	Size        int    // 字典大小（字节）
+	Samples     int    // 训练样本数，少于 8 个时不训练字典，Size 为 0
+	Used        bool   // 字典使归档变小时才保留，否则归档与不使用字典时相同
+	Sidecar     string // 旁路字典文件路径，字典嵌入归档或未保留时为空
+	WithDict    int64  // 使用字典时的大小，包括字典本身
+	WithoutDict int64  // 不使用字典时的压缩大小
+}
+
+// Saving 使用字典节省的比例（百分比），为负数表示字典反而使归档变大
+func (s DictStats) Saving() float64 {
+	if s.WithoutDict == 0 {
+		return 0
+	}
+	return float64(s.WithoutDict-s.WithDict) / float64(s.WithoutDict) * 100
+}
+
+// ValidateZstdDict 检查字典存放方式，空值表示不使用字典
+func ValidateZstdDict(mode string) error {
+	if mode != "" && !slices.Contains(ZstdDictModes, mode) {
+		return fmt.Errorf("不支持的 Zstd 字典存放方式 %q，可选 %s、%s", mode, ZstdDictEmbed, ZstdDictSidecar)
+	}
+	return nil
+}
+
+// validateZstdDictOptions 检查字典选项能否与其他选项同时使用
+func validateZstdDictOptions(opts CompressOptions) error {
+	if err := ValidateZstdDict(opts.ZstdDict); err != nil {
+		return err
+	}
+	if opts.ZstdDict != "" && opts.Reproducible && opts.Format == ".tar.zst" {
+		return fmt.Errorf("可复现模式不能使用 Zstd 字典：字典训练的结果每次不同")
+	}
+	return nil
+}
+
+// ZstdDictPath 旁路字典文件的路径
+func ZstdDictPath(archive string) string {
+	return archive + ".dict"
+}
+
+// trainZstdDict 从待压缩的文件中均匀抽取样本训练字典，返回字典和样本数
+// 非空文件少于 zstdDictMinSamples 个时训练出的字典没有意义，返回 nil 字典
+func trainZstdDict(ctx context.Context, files []string) ([]byte, int, error) {
+	step := max(len(files)/zstdDictMaxSamples, 1)
+	var samples [][]byte
+	sampleCRC := crc32.NewIEEE()
+	for i := 0; i < len(files) && len(samples) < zstdDictMaxSamples; i += step {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		sample, err := readZstdDictSample(files[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(sample) > 0 {
+			samples = append(samples, sample)
+			sampleCRC.Write(sample)
+		}
+	}
+	if len(samples) < zstdDictMinSamples {
+		return nil, len(samples), nil
+	}
+
+	// 字典 ID 取自样本的校验和，避开 zstd 保留的 0-32767
+	id := 32768 + sampleCRC.Sum32()%(1<<31-32768)
+	zdict, err := dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: zstdDictMaxSize,
+		HashBytes:   6,
+		ZstdDictID:  id,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("训练 Zstd 字典失败: %w", err)
+	}
+	return zdict, len(samples), nil
+}
+
+// readZstdDictSample 读取普通文件开头的内容作为样本，其他类型的条目返回 nil
+func readZstdDictSample(path string) ([]byte, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() || info.Size() == 0 {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sample := make([]byte, min(info.Size(), zstdDictSampleSize))
+	n, err := io.ReadFull(file, sample)
+	if err == io.ErrUnexpectedEOF {
+		err = nil // 文件在收集后被截短
+	}
+	return sample[:n], err
+}
+
+// zstdTaggedFrame 将内容包装为以 tag 开头的可跳过帧
+func zstdTaggedFrame(tag, payload []byte) []byte {
+	frame := make([]byte, 8, 8+len(tag)+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:], zstdDictFrameMagic)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(len(tag)+len(payload)))
+	frame = append(frame, tag...)
+	return append(frame, payload...)
+}
+
+// readZstdTaggedFrame 读取 offset 处以 tag 开头的可跳过帧的内容，不是这样的帧时返回 nil
+func readZstdTaggedFrame(file *os.File, offset int64, tag []byte, maxSize int64) ([]byte, error) {
+	header := make([]byte, 8+len(tag))
+	if _, err := file.ReadAt(header, offset); err != nil ||
+		binary.LittleEndian.Uint32(header[0:]) != zstdDictFrameMagic || !bytes.Equal(header[8:], tag) {
+		return nil, nil
+	}
+	size := int64(binary.LittleEndian.Uint32(header[4:])) - int64(len(tag))
+	if size <= 0 || size > maxSize {
+		return nil, fmt.Errorf("可跳过帧 %s 已损坏", tag)
+	}
+	payload := make([]byte, size)
+	if _, err := file.ReadAt(payload, offset+int64(len(header))); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// loadZstdDict 读取归档开头嵌入的字典，没有时读取旁路字典文件，都没有时返回 nil
+func loadZstdDict(file *os.File, source string) ([]byte, error) {
+	zdict, err := readZstdTaggedFrame(file, 0, zstdDictTag, zstdDictMaxSize*4)
+	if err != nil {
+		return nil, fmt.Errorf("读取 Zstd 字典失败: %w", err)
+	}
+	if zdict != nil {
+		return zdict, nil
+	}
+
+	zdict, err = os.ReadFile(ZstdDictPath(source))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 Zstd 字典失败: %w", err)
+	}
+	return zdict, nil
+}
+
+// zstdDictDecoderOptions 解码器参数，归档使用了字典时加入嵌入或旁路的字典
+func zstdDictDecoderOptions(file *os.File, opts ExtractOptions) ([]zstd.DOption, error) {
+	zdict, err := loadZstdDict(file, opts.Source)
+	if err != nil {
+		return nil, err
+	}
+	options := zstdDecoderOptions(opts)
+	if zdict != nil {
+		options = append(options, zstd.WithDecoderDicts(zdict))
+	}
+	return options, nil
+}
+
+// newZstdTarWriter 按选项创建可寻址或连续的 Zstd 写入器
+// zdict 不为 nil 时使用字典压缩，frame 是嵌入的字典帧，在压缩数据之前写入
+func newZstdTarWriter(w io.Writer, opts CompressOptions, zdict, frame []byte) (io.WriteCloser, error) {
+	if opts.ZstdSeekable {
+		sw, err := newZstdSeekableWriter(w, opts, zdict, frame)
+		if err != nil {
+			return nil, err
+		}
+		return sw, nil
+	}
+
+	if frame != nil {
+		if _, err := w.Write(frame); err != nil {
+			return nil, fmt.Errorf("写入 Zstd 字典失败: %w", err)
+		}
+	}
+	options := zstdEncoderOptions(opts)
+	if zdict != nil {
+		options = append(options, zstd.WithEncoderDict(zdict))
+	}
+	zw, err := zstd.NewWriter(w, options...)
+	if err != nil {
+		return nil, err
+	}
+	return zw, nil
+}
+
+// compressTarZstdDict 训练字典后压缩，同时生成不使用字典的压缩流，保留较小的一个
+// 连续的压缩流中后面的文件已经能引用前面的内容，字典通常只对很小的归档或可寻址格式的独立帧有帮助
+func compressTarZstdDict(ctx context.Context, files []string, outFile *os.File, opts CompressOptions, stats *CompressStats) error {
+	zdict, samples, err := trainZstdDict(ctx, files)
+	if err != nil {
+		return err
+	}
+	if zdict == nil {
+		// 样本太少，直接写入不使用字典的压缩流
+		stats.ZstdDict = &DictStats{Samples: samples}
+		zstdWriter, err := newZstdTarWriter(outFile, opts, nil, nil)
+		if err != nil {
+			return fmt.Errorf("创建 Zstd 写入器失败: %w", err)
+		}
+		defer zstdWriter.Close()
+		if err := compressTar(ctx, files, zstdWriter, opts, stats); err != nil {
+			return err
+		}
+		return zstdWriter.Close()
+	}
+
+	var frame []byte
+	if opts.ZstdDict == ZstdDictEmbed {
+		frame = zstdTaggedFrame(zstdDictTag, zdict)
+	}
+	zstdWriter, err := newZstdTarWriter(outFile, opts, zdict, frame)
+	if err != nil {
+		return fmt.Errorf("创建 Zstd 写入器失败: %w", err)
+	}
+	defer zstdWriter.Close()
+
+	// 不使用字典的压缩流先写入内存，过大时转存到输出目录中的临时文件
+	plain := &spillBuffer{dir: filepath.Dir(opts.Output)}
+	defer plain.Close()
+	plainWriter, err := newZstdTarWriter(plain, opts, nil, nil)
+	if err != nil {
+		return fmt.Errorf("创建 Zstd 写入器失败: %w", err)
+	}
+	defer plainWriter.Close()
+
+	if err := compressTar(ctx, files, io.MultiWriter(zstdWriter, plainWriter), opts, stats); err != nil {
+		return err
+	}
+	if err := zstdWriter.Close(); err != nil {
+		return err
+	}
+	if err := plainWriter.Close(); err != nil {
+		return err
+	}
+
+	written, err := outFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	dictStats := &DictStats{Size: len(zdict), Samples: samples, WithDict: written, WithoutDict: plain.size}
+	if frame == nil {
+		dictStats.WithDict += int64(len(zdict))
+	}
+	stats.ZstdDict = dictStats
+
+	if dictStats.WithDict >= dictStats.WithoutDict {
+		// 字典没有带来收益，改为不使用字典的压缩流
+		if err := outFile.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := outFile.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		_, err := plain.WriteTo(outFile)
+		return err
+	}
+
+	dictStats.Used = true
+	if frame == nil {
+		dictStats.Sidecar = ZstdDictPath(opts.Output)
+		if err := os.WriteFile(dictStats.Sidecar, zdict, 0644); err != nil {
+			return fmt.Errorf("写入 Zstd 字典失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+package archiver
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 可寻址 Zstd 格式（zstd contrib/seekable_format）：数据分成独立压缩的帧，
+// 文件末尾的可跳过帧记录每一帧压缩前后的大小，zstd -d 会跳过该帧，仍能正常解压
+// 本程序在数据帧和寻址表之间再写入一个带标记的可跳过帧，保存 TAR 条目索引
+const (
+	zstdSeekTableMagic  = 0x184D2A5E
+	zstdSeekableMagic   = 0x8F92EAB1
+	zstdSeekFooterSize  = 9 // 帧数、描述符和魔数
+	zstdSeekChecksumBit = 0x80
+
+	// MinZstdFrameSize / MaxZstdFrameSize 可寻址格式每帧未压缩数据的大小范围
+	MinZstdFrameSize = 64 << 10
+	MaxZstdFrameSize = 256 << 20
+	// zstdIndexMaxSize 索引帧的大小上限，防止损坏的文件申请过多内存
+	zstdIndexMaxSize = 1 << 30
+)
+
+// zstdIndexTag 索引帧内容的开头
+var zstdIndexTag = []byte("SAZI")
+
+// ValidateZstdFrameSize 检查可寻址格式的帧大小，0 表示使用默认值
+func ValidateZstdFrameSize(size int) error {
+	if size != 0 && (size < MinZstdFrameSize || size > MaxZstdFrameSize) {
+		return fmt.Errorf("Zstd 帧大小必须在 %d-%d 字节之间: %d", MinZstdFrameSize, MaxZstdFrameSize, size)
+	}
+	return nil
+}
+
+// validateZstdSeekableOptions 检查可寻址格式的参数
+func validateZstdSeekableOptions(opts CompressOptions) error {
+	return ValidateZstdFrameSize(opts.ZstdFrameSize)
+}
+
+// zstdFrame 一个编码好的帧
+type zstdFrame struct {
+	data []byte
+	raw  int // 未压缩大小
+}
+
+// zstdSeekableWriter 将 TAR 流按固定大小分帧并行压缩，关闭时写入索引和寻址表
+type zstdSeekableWriter struct {
+	w       io.Writer
+	encoder *zstd.Encoder
+	blocks  *blockWriter[zstdFrame]
+	index   *tarIndexer
+	sizes   [][2]uint32 // 每帧压缩后、压缩前的大小
+	closed  bool
+}
+
+// newZstdSeekableWriter 创建可寻址写入器，帧之间不共享数据，因此不使用窗口大小参数
+// zdict 不为 nil 时每帧都使用字典压缩；frame 是嵌入的字典帧，写在数据帧之前，寻址表中记为未压缩大小为 0 的帧
+func newZstdSeekableWriter(w io.Writer, opts CompressOptions, zdict, frame []byte) (*zstdSeekableWriter, error) {
+	options := []zstd.EOption{
+		zstd.WithEncoderLevel(zstdLevel(opts.Level)),
+		zstd.WithEncoderConcurrency(resolveWorkers(opts.Workers)),
+	}
+	if zdict != nil {
+		options = append(options, zstd.WithEncoderDict(zdict))
+	}
+	encoder, err := zstd.NewWriter(nil, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	frameSize := opts.ZstdFrameSize
+	if frameSize == 0 {
+		frameSize = 1 << 20
+	}
+	sw := &zstdSeekableWriter{w: w, encoder: encoder, index: newTarIndexer()}
+	if frame != nil {
+		if _, err := w.Write(frame); err != nil {
+			encoder.Close()
+			return nil, fmt.Errorf("写入 Zstd 字典失败: %w", err)
+		}
+		sw.sizes = append(sw.sizes, [2]uint32{uint32(len(frame)), 0})
+	}
+	sw.blocks = newBlockWriter(frameSize, opts.Workers, func(block []byte) (zstdFrame, error) {
+		return zstdFrame{data: encoder.EncodeAll(block, nil), raw: len(block)}, nil
+	}, func(frame zstdFrame) error {
+		sw.sizes = append(sw.sizes, [2]uint32{uint32(len(frame.data)), uint32(frame.raw)})
+		_, err := w.Write(frame.data)
+		return err
+	})
+	return sw, nil
+}
+
+func (sw *zstdSeekableWriter) Write(p []byte) (int, error) {
+	if _, err := sw.index.Write(p); err != nil {
+		return 0, err
+	}
+	return sw.blocks.Write(p)
+}
+
+// Close 写出剩余的帧、索引帧和寻址表，重复调用时不再写入
+func (sw *zstdSeekableWriter) Close() error {
+	if sw.closed {
+		return sw.blocks.Close()
+	}
+	sw.closed = true
+	defer sw.encoder.Close()
+
+	err := sw.blocks.Close()
+	index, indexErr := sw.index.Finish()
+	if err != nil {
+		return err
+	}
+	if indexErr != nil {
+		return fmt.Errorf("生成 TAR 索引失败: %w", indexErr)
+	}
+
+	data, err := index.marshal()
+	if err != nil {
+		return err
+	}
+	if _, err := sw.w.Write(zstdTaggedFrame(zstdIndexTag, sw.encoder.EncodeAll(data, nil))); err != nil {
+		return err
+	}
+	_, err = sw.w.Write(zstdSeekTable(sw.sizes))
+	return err
+}
+
+// compressTarZstdSeekable 以可寻址格式写入 TAR.ZST
+func compressTarZstdSeekable(ctx context.Context, files []string, outFile *os.File, opts CompressOptions, stats *CompressStats) error {
+	zstdWriter, err := newZstdSeekableWriter(outFile, opts, nil, nil)
+	if err != nil {
+		return fmt.Errorf("创建 Zstd 写入器失败: %w", err)
+	}
+	defer zstdWriter.Close()
+
+	if err := compressTar(ctx, files, zstdWriter, opts, stats); err != nil {
+		return err
+	}
+	return zstdWriter.Close()
+}
+
+// zstdSeekTable 生成寻址表可跳过帧，不带校验值（每帧自身已有校验和）
+func zstdSeekTable(sizes [][2]uint32) []byte {
+	size := len(sizes)*8 + zstdSeekFooterSize
+	table := make([]byte, 8, 8+size)
+	binary.LittleEndian.PutUint32(table[0:], zstdSeekTableMagic)
+	binary.LittleEndian.PutUint32(table[4:], uint32(size))
+	for _, s := range sizes {
+		table = binary.LittleEndian.AppendUint32(table, s[0])
+		table = binary.LittleEndian.AppendUint32(table, s[1])
+	}
+	table = binary.LittleEndian.AppendUint32(table, uint32(len(sizes)))
+	table = append(table, 0)
+	return binary.LittleEndian.AppendUint32(table, zstdSeekableMagic)
+}
+
+// zstdSeekFrame 寻址表中的一帧
+type zstdSeekFrame struct {
+	offset    int64 // 在文件中的位置
+	size      int64
+	rawOffset int64 // 在未压缩流中的位置
+	rawSize   int64
+}
+
+// zstdSeekable 打开的可寻址 Zstd 文件
+type zstdSeekable struct {
+	file    *os.File
+	frames  []zstdSeekFrame
+	end     int64 // 数据帧结束的位置
+	decoder *zstd.Decoder
+
+	// 最近解码的一帧，相邻的条目常位于同一帧中
+	cached     int
+	cachedData []byte
+}
+
+// openZstdSeekable 读取文件末尾的寻址表，不是可寻址格式时返回 nil
+func openZstdSeekable(file *os.File, opts ExtractOptions) (*zstdSeekable, error) {
+	frames, end, err := readZstdSeekTable(file)
+	if err != nil || frames == nil {
+		return nil, err
+	}
+	decoderOptions, err := zstdDictDecoderOptions(file, opts)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := zstd.NewReader(nil, decoderOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("创建 Zstd 读取器失败: %w", err)
+	}
+	return &zstdSeekable{file: file, frames: frames, end: end, decoder: decoder, cached: -1}, nil
+}
+
+// readZstdSeekTable 解析寻址表，返回各帧的位置和数据帧结束的位置
+// 寻址表损坏或记录的大小不合理时返回 nil，按普通的 Zstd 流解码，不按表中的大小申请内存
+func readZstdSeekTable(file *os.File) ([]zstdSeekFrame, int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.Size() < 8+zstdSeekFooterSize {
+		return nil, 0, nil
+	}
+
+	footer := make([]byte, zstdSeekFooterSize)
+	if _, err := file.ReadAt(footer, info.Size()-zstdSeekFooterSize); err != nil {
+		return nil, 0, err
+	}
+	if binary.LittleEndian.Uint32(footer[5:]) != zstdSeekableMagic {
+		return nil, 0, nil
+	}
+
+	count := int64(binary.LittleEndian.Uint32(footer[0:]))
+	entrySize := int64(8)
+	if footer[4]&zstdSeekChecksumBit != 0 {
+		entrySize = 12
+	}
+	tableSize := count*entrySize + zstdSeekFooterSize
+	tableStart := info.Size() - tableSize - 8
+	if tableStart < 0 {
+		return nil, 0, nil
+	}
+
+	table := make([]byte, tableSize+8)
+	if _, err := file.ReadAt(table, tableStart); err != nil {
+		return nil, 0, err
+	}
+	if binary.LittleEndian.Uint32(table[0:]) != zstdSeekTableMagic || int64(binary.LittleEndian.Uint32(table[4:])) != tableSize {
+		return nil, 0, nil
+	}
+
+	frames := make([]zstdSeekFrame, count)
+	var offset, rawOffset int64
+	for i := range frames {
+		entry := table[8+int64(i)*entrySize:]
+		frames[i] = zstdSeekFrame{
+			offset:    offset,
+			size:      int64(binary.LittleEndian.Uint32(entry[0:])),
+			rawOffset: rawOffset,
+			rawSize:   int64(binary.LittleEndian.Uint32(entry[4:])),
+		}
+		// 未压缩大小不超过写入时允许的帧大小，只有可跳过帧（如嵌入的字典）的未压缩大小为 0
+		if frames[i].size == 0 || frames[i].rawSize > MaxZstdFrameSize {
+			return nil, 0, nil
+		}
+		offset += frames[i].size
+		rawOffset += frames[i].rawSize
+		if offset > tableStart {
+			return nil, 0, nil
+		}
+	}
+	return frames, offset, nil
+}
+
+// Close 释放解码器
+func (s *zstdSeekable) Close() {
+	s.decoder.Close()
+}
+
+// decodeFrame 解码第 i 帧，可以并发调用
+func (s *zstdSeekable) decodeFrame(i int) ([]byte, error) {
+	frame := s.frames[i]
+	if frame.rawSize == 0 {
+		// 嵌入的字典帧等可跳过帧，没有需要输出的数据
+		return nil, nil
+	}
+	data := make([]byte, frame.size)
+	if _, err := s.file.ReadAt(data, frame.offset); err != nil {
+		return nil, err
+	}
+	// 帧头记录的大小与寻址表不符时不解码，避免按损坏的帧头输出过多数据
+	var header zstd.Header
+	if err := header.Decode(data); err != nil {
+		return nil, err
+	}
+	if header.HasFCS && header.FrameContentSize != uint64(frame.rawSize) {
+		return nil, fmt.Errorf("Zstd 帧 %d 的大小与寻址表不符", i)
+	}
+	raw, err := s.decoder.DecodeAll(data, make([]byte, 0, frame.rawSize))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) != frame.rawSize {
+		return nil, fmt.Errorf("Zstd 帧 %d 的大小与寻址表不符", i)
+	}
+	return raw, nil
+}
+
+// stream 按顺序并行解码所有帧
+func (s *zstdSeekable) stream(workers int) io.ReadCloser {
+	return newOrderedReader(len(s.frames), workers, s.decodeFrame, nil)
+}
+
+// tarStream 指定了 Entries 且归档带有索引时返回只包含所选条目的 TAR 流，否则并行解码整个流
+func (s *zstdSeekable) tarStream(opts ExtractOptions) (io.ReadCloser, error) {
+	if len(opts.Entries) > 0 {
+		index, err := s.tarIndex()
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if index != nil {
+			return &zstdSeekableReader{Reader: index.selectedTarStream(opts.Entries, s.section), s: s}, nil
+		}
+	}
+	return &zstdSeekableReader{Reader: s.stream(opts.Workers), s: s}, nil
+}
+
+// zstdSeekableReader 关闭时同时释放解码器
+type zstdSeekableReader struct {
+	io.Reader
+	s *zstdSeekable
+}
+
+func (r *zstdSeekableReader) Close() error {
+	var err error
+	if c, ok := r.Reader.(io.Closer); ok {
+		err = c.Close()
+	}
+	r.s.Close()
+	return err
+}
+
+// section 读取未压缩流中 [start, end) 的内容，只解码覆盖这一段的帧
+func (s *zstdSeekable) section(start, end int64) io.Reader {
+	return &zstdSection{s: s, pos: start, end: end}
+}
+
+// zstdSection 未压缩流中的一段，读取时才解码所需的帧
+type zstdSection struct {
+	s        *zstdSeekable
+	pos, end int64
+}
+
+func (r *zstdSection) Read(p []byte) (int, error) {
+	if r.pos >= r.end {
+		return 0, io.EOF
+	}
+	s := r.s
+	i := sort.Search(len(s.frames), func(i int) bool {
+		return s.frames[i].rawOffset+s.frames[i].rawSize > r.pos
+	})
+	if i == len(s.frames) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if s.cached != i {
+		data, err := s.decodeFrame(i)
+		if err != nil {
+			return 0, err
+		}
+		s.cached, s.cachedData = i, data
+	}
+	frame := s.frames[i]
+	from := r.pos - frame.rawOffset
+	to := min(r.end, frame.rawOffset+frame.rawSize) - frame.rawOffset
+	n := copy(p, s.cachedData[from:to])
+	r.pos += int64(n)
+	return n, nil
+}
+
+// tarIndex 读取数据帧之后的索引帧，没有索引时返回 nil
+func (s *zstdSeekable) tarIndex() (*tarIndex, error) {
+	payload, err := readZstdTaggedFrame(s.file, s.end, zstdIndexTag, zstdIndexMaxSize)
+	if err != nil || payload == nil {
+		return nil, err
+	}
+	data, err := s.decoder.DecodeAll(payload, nil)
+	if err != nil {
+		return nil, fmt.Errorf("读取 TAR 索引失败: %w", err)
+	}
+	index, err := unmarshalTarIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("读取 TAR 索引失败: %w", err)
+	}
+	return index, nil
+}
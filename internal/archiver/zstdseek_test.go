@@ -0,0 +1,271 @@
+package archiver
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// writeSeekableTestArchive 压缩 count 个文件为可寻址 TAR.ZST，每帧 64KB，返回归档路径和各文件内容
+func writeSeekableTestArchive(t *testing.T, dir string, count int) (string, map[string][]byte) {
+	t.Helper()
+	source := filepath.Join(dir, "src")
+	want := make(map[string][]byte)
+	for i := range count {
+		name := fmt.Sprintf("src/d%d/f%02d.bin", i%3, i)
+		data := xzTestData(20000 + i*3001)
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		want[name] = data
+	}
+
+	archive := filepath.Join(dir, "seek.tar.zst")
+	opts := CompressOptions{Source: source, Output: archive, Format: ".tar.zst", ZstdSeekable: true, ZstdFrameSize: MinZstdFrameSize, Workers: 4}
+	if _, err := Compress(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	return archive, want
+}
+
+// openTestSeekable 打开可寻址归档，读取寻址表和 TAR 索引
+func openTestSeekable(t *testing.T, path string) (*zstdSeekable, *tarIndex) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	s, err := openZstdSeekable(file, ExtractOptions{Source: path})
+	if err != nil || s == nil {
+		t.Fatalf("openZstdSeekable = %v, %v", s, err)
+	}
+	t.Cleanup(s.Close)
+	index, err := s.tarIndex()
+	if err != nil || index == nil {
+		t.Fatalf("tarIndex = %v, %v", index, err)
+	}
+	return s, index
+}
+
+// corruptFrames 破坏未压缩区段与 keep 不相交的数据帧，返回破坏的帧数
+func corruptFrames(t *testing.T, path string, frames []zstdSeekFrame, keep [][2]int64) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := 0
+	for _, frame := range frames {
+		overlaps := false
+		for _, k := range keep {
+			if frame.rawOffset < k[1] && frame.rawOffset+frame.rawSize > k[0] {
+				overlaps = true
+			}
+		}
+		if !overlaps && frame.rawSize > 0 {
+			// 保留帧头，破坏压缩数据
+			for i := frame.offset + frame.size/2; i < frame.offset+frame.size-4; i++ {
+				data[i] ^= 0x55
+			}
+			corrupted++
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return corrupted
+}
+
+func TestZstdSeekableIndex(t *testing.T) {
+	dir := t.TempDir()
+	archive, want := writeSeekableTestArchive(t, dir, 40)
+	s, index := openTestSeekable(t, archive)
+	if len(s.frames) < 10 {
+		t.Fatalf("got %d frames, want at least 10", len(s.frames))
+	}
+
+	// 只保留所选条目所在的帧，其他帧都被破坏：按索引解压仍然成功，解压整个归档失败
+	const selected = "src/d1/f31.bin"
+	sections := index.selection([]string{selected})
+	if len(sections) != 1 {
+		t.Fatalf("selection(%q) = %v", selected, sections)
+	}
+	if n := corruptFrames(t, archive, s.frames, sections); n < len(s.frames)/2 {
+		t.Fatalf("only %d of %d frames were corrupted", n, len(s.frames))
+	}
+
+	output := filepath.Join(dir, "selected")
+	if _, err := Extract(context.Background(), ExtractOptions{Source: archive, Output: output, Entries: []string{selected}}); err != nil {
+		t.Fatalf("extracting %s through the index: %v", selected, err)
+	}
+	got, err := os.ReadFile(filepath.Join(output, filepath.FromSlash(selected)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want[selected]) {
+		t.Fatalf("%s has wrong content", selected)
+	}
+	if _, err := Extract(context.Background(), ExtractOptions{Source: archive, Output: filepath.Join(dir, "all")}); err == nil {
+		t.Fatal("extracting every entry should read the corrupted frames and fail")
+	}
+
+	// 列出条目只读取索引
+	corruptFrames(t, archive, s.frames, nil)
+	entries, err := List(context.Background(), ExtractOptions{Source: archive})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	files := 0
+	for _, entry := range entries {
+		if !entry.IsDir {
+			if data, ok := want[entry.Name]; !ok || entry.Size != int64(len(data)) {
+				t.Fatalf("List returned %q with size %d", entry.Name, entry.Size)
+			}
+			files++
+		}
+	}
+	if files != len(want) {
+		t.Fatalf("List returned %d files, want %d", files, len(want))
+	}
+}
+
+func TestZstdSeekablePlainDecoder(t *testing.T) {
+	archive, want := writeSeekableTestArchive(t, t.TempDir(), 20)
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 普通的 Zstd 解码器跳过索引帧和寻址表，得到与并行解码相同的 TAR 流
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("zstd.NewReader: %v", err)
+	}
+
+	s, _ := openTestSeekable(t, archive)
+	stream := s.stream(4)
+	defer stream.Close()
+	parallel, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plain, parallel) {
+		t.Fatal("plain and parallel decoding differ")
+	}
+
+	tr := tar.NewReader(bytes.NewReader(plain))
+	files := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		got, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want[header.Name]) {
+			t.Fatalf("%s has wrong content", header.Name)
+		}
+		files++
+	}
+	if files != len(want) {
+		t.Fatalf("TAR stream has %d files, want %d", files, len(want))
+	}
+}
+
+func TestZstdSeekTableFallback(t *testing.T) {
+	dir := t.TempDir()
+	archive, want := writeSeekableTestArchive(t, dir, 12)
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := int(binary.LittleEndian.Uint32(data[len(data)-zstdSeekFooterSize:]))
+	tableStart := len(data) - zstdSeekFooterSize - count*8 - 8
+
+	// rawSize 写入 entry 表中第 i 帧的未压缩大小
+	rawSize := func(i int, size uint32) []byte {
+		forged := bytes.Clone(data)
+		binary.LittleEndian.PutUint32(forged[tableStart+8+i*8+4:], size)
+		return forged
+	}
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"huge-frame", rawSize(0, 0xfffffff0)},
+		{"over-max", rawSize(count-1, MaxZstdFrameSize+1)},
+		{"zero-size", func() []byte {
+			forged := bytes.Clone(data)
+			binary.LittleEndian.PutUint32(forged[tableStart+8:], 0)
+			return forged
+		}()},
+		{"past-end", func() []byte {
+			forged := bytes.Clone(data)
+			binary.LittleEndian.PutUint32(forged[tableStart+8:], uint32(len(data)))
+			return forged
+		}()},
+		{"bad-table-magic", func() []byte {
+			forged := bytes.Clone(data)
+			forged[tableStart] ^= 0xff
+			return forged
+		}()},
+		{"truncated", data[:len(data)-1]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name+".tar.zst")
+			if err := os.WriteFile(path, tc.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			frames, _, err := readZstdSeekTable(file)
+			if err != nil || frames != nil {
+				t.Fatalf("readZstdSeekTable should reject the table, got %d frames, %v", len(frames), err)
+			}
+
+			// 不再按寻址表解码，整个流按普通 Zstd 解码
+			output := filepath.Join(dir, tc.name)
+			if _, err := Extract(context.Background(), ExtractOptions{Source: path, Output: output}); err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			for name, data := range want {
+				got, err := os.ReadFile(filepath.Join(output, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, data) {
+					t.Fatalf("%s has wrong content", name)
+				}
+			}
+		})
+	}
+}
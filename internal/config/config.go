@@ -1,6 +1,8 @@
 // Package config 提供压缩工具的配置管理
 package config
 
+import "sort"
+
 // DefaultExcludes 默认排除模式列表
 var DefaultExcludes = []string{
 	// Python
@@ -40,11 +42,93 @@ type ExcludeCategory struct {
 	Name     string
 	Patterns []string
 	Selected bool
+	// IgnoreFiles 为 true 时 Patterns 是忽略文件名，按其中的 gitignore 规则排除
+	IgnoreFiles bool
+	// Projects 非空时只有检测到这些项目类型才默认选中（如 build 目录也可能是源码）
+	Projects []string
+	// Custom 用户添加的类别
+	Custom bool
+
+	// 扫描结果
+	Matches []string // 实际匹配的路径
+	Size    int64    // 排除后可节省的字节数
+}
+
+// ProjectMarkers 标记文件 → 项目类型
+var ProjectMarkers = map[string]string{
+	"go.mod":           "Go",
+	"Cargo.toml":       "Rust",
+	"package.json":     "Node.js",
+	"pyproject.toml":   "Python",
+	"setup.py":         "Python",
+	"requirements.txt": "Python",
+	"Pipfile":          "Python",
+	"pom.xml":          "Java",
+	"build.gradle":     "Java",
+	"build.gradle.kts": "Java",
+	"composer.json":    "PHP",
+	"CMakeLists.txt":   "C/C++",
+}
+
+// DetectProjects 根据找到的标记文件返回项目类型列表（去重、按标记文件名排序）
+func DetectProjects(found map[string]bool) []string {
+	markers := make([]string, 0, len(found))
+	for marker := range found {
+		markers = append(markers, marker)
+	}
+	sort.Strings(markers)
+
+	var projects []string
+	seen := make(map[string]bool)
+	for _, marker := range markers {
+		project, ok := ProjectMarkers[marker]
+		if ok && !seen[project] {
+			seen[project] = true
+			projects = append(projects, project)
+		}
+	}
+	return projects
+}
+
+// ApplyScan 根据扫描结果筛选排除类别
+// 只保留实际匹配到内容的类别，需要项目类型的类别在未检测到对应项目时不默认选中
+func ApplyScan(categories []ExcludeCategory, matches [][]string, sizes []int64, projects []string) []ExcludeCategory {
+	var result []ExcludeCategory
+	for i, cat := range categories {
+		if len(matches[i]) == 0 {
+			continue
+		}
+		cat.Matches = matches[i]
+		cat.Size = sizes[i]
+		if len(cat.Projects) > 0 {
+			cat.Selected = containsAny(cat.Projects, projects)
+		}
+		result = append(result, cat)
+	}
+	return result
+}
+
+// containsAny 判断两个列表是否有共同元素
+func containsAny(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // GetExcludeCategories 获取排除类别列表
 func GetExcludeCategories() []ExcludeCategory {
 	return []ExcludeCategory{
+		{
+			Name:        "忽略文件规则",
+			Patterns:    []string{".gitignore", ".ignore", ".archiveignore"},
+			Selected:    true,
+			IgnoreFiles: true,
+		},
 		{
 			Name:     "Python 相关",
 			Patterns: []string{"venv", ".venv", "__pycache__", "*.pyc", "*.pyo", ".pytest_cache", ".mypy_cache", "*.egg-info", ".eggs"},
@@ -69,6 +153,7 @@ func GetExcludeCategories() []ExcludeCategory {
 			Name:     "构建产物",
 			Patterns: []string{"dist", "build", "target", "out"},
 			Selected: true,
+			Projects: []string{"Go", "Rust", "Node.js", "Python", "Java", "PHP", "C/C++"},
 		},
 		{
 			Name:     "系统文件",
@@ -84,6 +169,7 @@ func GetExcludeCategories() []ExcludeCategory {
 			Name:     "Go 依赖",
 			Patterns: []string{"vendor"},
 			Selected: true,
+			Projects: []string{"Go"},
 		},
 		{
 			Name:     "Java/Gradle 相关",
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/BurntSushi/toml"
+)
+
+// appDirName 配置目录名
+const appDirName = "simplearchiver"
+
+// customCategoriesFile 保存自定义排除类别的文件
+const customCategoriesFile = "categories.toml"
+
+// customCategoriesDoc categories.toml 的结构
+type customCategoriesDoc struct {
+	Category []customCategory `toml:"category"`
+}
+
+// customCategory 保存的自定义排除类别
+type customCategory struct {
+	Name     string   `toml:"name"`
+	Patterns []string `toml:"patterns"`
+}
+
+// Dir 返回配置目录
+// 优先使用 $XDG_CONFIG_HOME，其次 ~/.config，Windows 上使用 %AppData%
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, appDirName), nil
+	}
+	if runtime.GOOS == "windows" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, appDirName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", appDirName), nil
+}
+
+// customCategoriesPath 返回自定义类别文件路径
+func customCategoriesPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", fmt.Errorf("获取配置目录失败: %w", err)
+	}
+	return filepath.Join(dir, customCategoriesFile), nil
+}
+
+// LoadCustomCategories 读取保存的自定义排除类别，文件不存在时返回空列表
+func LoadCustomCategories() ([]ExcludeCategory, error) {
+	path, err := customCategoriesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var doc customCategoriesDoc
+	if _, err := toml.DecodeFile(path, &doc); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取自定义排除类别失败 %s: %w", path, err)
+	}
+
+	categories := make([]ExcludeCategory, 0, len(doc.Category))
+	for _, c := range doc.Category {
+		categories = append(categories, ExcludeCategory{
+			Name:     c.Name,
+			Patterns: c.Patterns,
+			Selected: true,
+			Custom:   true,
+		})
+	}
+	return categories, nil
+}
+
+// SaveCustomCategory 保存自定义排除类别，同名类别会被覆盖
+func SaveCustomCategory(cat ExcludeCategory) error {
+	if cat.Name == "" {
+		return fmt.Errorf("类别名称不能为空")
+	}
+
+	existing, err := LoadCustomCategories()
+	if err != nil {
+		return err
+	}
+
+	var doc customCategoriesDoc
+	replaced := false
+	for _, c := range existing {
+		if c.Name == cat.Name {
+			c.Patterns = cat.Patterns
+			replaced = true
+		}
+		doc.Category = append(doc.Category, customCategory{Name: c.Name, Patterns: c.Patterns})
+	}
+	if !replaced {
+		doc.Category = append(doc.Category, customCategory{Name: cat.Name, Patterns: cat.Patterns})
+	}
+
+	path, err := customCategoriesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+
+	if err := writeTOML(path, doc); err != nil {
+		return fmt.Errorf("保存自定义排除类别失败: %w", err)
+	}
+	return nil
+}
+
+// writeTOML 将 v 编码为 TOML 写入同目录的临时文件，再改名替换 path
+// 写入中途失败或被中断时原文件保持不变
+func writeTOML(path string, v any) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := toml.NewEncoder(tmp).Encode(v); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
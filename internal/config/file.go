@@ -0,0 +1,316 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置文件名，按顺序查找
+var configFileNames = []string{"config.toml", "config.yaml", "config.yml"}
+
+// Themes 可选的界面主题
+var Themes = []string{"dark", "light"}
+
+// 解压方式
+const (
+	ExtractModeSmart  = "smart"  // 只有一个顶层目录时直接解压，否则放入新文件夹
+	ExtractModeFolder = "folder" // 始终解压到以归档命名的新文件夹
+	ExtractModeHere   = "here"   // 直接解压到归档所在目录
+)
+
+// ExtractModes 可选的解压方式
+var ExtractModes = []string{ExtractModeSmart, ExtractModeFolder, ExtractModeHere}
+
+// ThemeColors 可以在配置中覆盖的颜色
+var ThemeColors = []string{"primary", "secondary", "success", "warning", "error", "muted", "foreground", "border", "archive"}
+
+// hexColorPattern 颜色格式 #RRGGBB
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Config 用户配置文件
+type Config struct {
+	// Language 界面语言（en/zh），为空时跟随系统
+	Language string `toml:"language" yaml:"language"`
+	// Theme 界面主题（dark/light）
+	Theme string `toml:"theme" yaml:"theme"`
+	// Colors 覆盖主题中的颜色，如 primary = "#7C3AED"
+	Colors map[string]string `toml:"colors" yaml:"colors"`
+
+	Defaults Defaults      `toml:"defaults" yaml:"defaults"`
+	Excludes ExcludeConfig `toml:"excludes" yaml:"excludes"`
+}
+
+// Defaults 压缩默认值
+type Defaults struct {
+	Format    string `toml:"format" yaml:"format"`         // 默认格式，如 zip、tar.zst
+	Level     int    `toml:"level" yaml:"level"`           // 压缩级别 1-9，0 使用格式默认值
+	OutputDir string `toml:"output_dir" yaml:"output_dir"` // 输出目录，为空时与源文件同目录
+
+	// 输出命名模板，可用变量见 OutputVars
+	NameTemplate    string `toml:"name_template" yaml:"name_template"`       // 压缩输出，默认 {name}{ext}
+	ExtractTemplate string `toml:"extract_template" yaml:"extract_template"` // 解压目录，默认 {name}
+
+	ExtractMode string `toml:"extract_mode" yaml:"extract_mode"` // 解压方式：smart（默认）、folder、here
+
+	BatchWorkers int `toml:"batch_workers" yaml:"batch_workers"` // 批量任务的并发数，0 使用默认值
+	Workers      int `toml:"workers" yaml:"workers"`             // 单个任务的并行数，0 使用全部 CPU 核
+
+	// Gzip / Zstd 流参数，大小写法同 ParseSize（如 4MB），为空使用默认值
+	GzipBlockSize  string `toml:"gzip_block_size" yaml:"gzip_block_size"`   // TAR.GZ 并行压缩和预读的块大小
+	GzipIndex      bool   `toml:"gzip_index" yaml:"gzip_index"`             // 压缩 TAR.GZ 时同时建立随机访问索引
+	ZstdWindowSize string `toml:"zstd_window_size" yaml:"zstd_window_size"` // Zstd 窗口大小，1KB-512MB 之间的 2 的幂
+	ZstdLong       bool   `toml:"zstd_long" yaml:"zstd_long"`               // Zstd 长距离模式（128MB 窗口）
+	ZstdMaxMemory  string `toml:"zstd_max_memory" yaml:"zstd_max_memory"`   // 解压 Zstd 时允许的最大窗口
+	ZstdDict       string `toml:"zstd_dict" yaml:"zstd_dict"`               // 训练 Zstd 字典：embed、sidecar，为空不使用
+	ZstdSeekable   bool   `toml:"zstd_seekable" yaml:"zstd_seekable"`       // TAR.ZST 写成可寻址格式，附带条目索引
+	ZstdFrameSize  string `toml:"zstd_frame_size" yaml:"zstd_frame_size"`   // 可寻址格式每帧的大小，64KB-256MB
+}
+
+// ExcludeConfig 排除类别的调整
+type ExcludeConfig struct {
+	Remove   []string          `toml:"remove" yaml:"remove"`     // 移除的内置类别名称
+	Rename   map[string]string `toml:"rename" yaml:"rename"`     // 内置类别重命名：原名称 → 新名称
+	Category []CategoryConfig  `toml:"category" yaml:"category"` // 新增的类别
+}
+
+// CategoryConfig 配置文件中的排除类别
+type CategoryConfig struct {
+	Name     string   `toml:"name" yaml:"name"`
+	Patterns []string `toml:"patterns" yaml:"patterns"`
+	Selected *bool    `toml:"selected" yaml:"selected"` // 默认选中，省略时为 true
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{}
+}
+
+// Load 从配置目录读取配置文件，不存在时返回默认配置
+// 返回实际读取的文件路径，未找到时为空
+func Load() (*Config, string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, "", fmt.Errorf("获取配置目录失败: %w", err)
+	}
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			cfg, err := LoadFile(path)
+			return cfg, path, err
+		}
+	}
+	return Default(), "", nil
+}
+
+// LoadFile 读取并校验指定的配置文件，按扩展名选择 TOML 或 YAML
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	cfg := Default()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("配置文件 %s 格式错误: %w", path, err)
+		}
+	default:
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("配置文件 %s 格式错误: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return nil, fmt.Errorf("配置文件 %s 包含未知的配置项: %s", path, strings.Join(keys, ", "))
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("配置文件 %s 无效:\n%w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate 校验配置取值，返回所有问题
+// 模板、并行数和流参数的取值范围依赖归档实现，由调用方另行校验
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Language {
+	case "", "auto", "en", "zh":
+	default:
+		errs = append(errs, fmt.Errorf("  language: 不支持的语言 %q，可选 auto、en、zh", c.Language))
+	}
+
+	if c.Theme != "" && !slices.Contains(Themes, c.Theme) {
+		errs = append(errs, fmt.Errorf("  theme: 不支持的主题 %q，可选 %s", c.Theme, strings.Join(Themes, "、")))
+	}
+	for _, name := range sortedKeys(c.Colors) {
+		if !slices.Contains(ThemeColors, name) {
+			errs = append(errs, fmt.Errorf("  colors.%s: 未知的颜色，可选 %s", name, strings.Join(ThemeColors, "、")))
+		} else if !hexColorPattern.MatchString(c.Colors[name]) {
+			errs = append(errs, fmt.Errorf("  colors.%s: 颜色格式应为 #RRGGBB: %q", name, c.Colors[name]))
+		}
+	}
+
+	if c.Defaults.Format != "" && c.DefaultFormatIndex() < 0 {
+		var names []string
+		for _, f := range GetArchiveFormats() {
+			names = append(names, strings.TrimPrefix(f.Extension, "."))
+		}
+		errs = append(errs, fmt.Errorf("  defaults.format: 不支持的格式 %q，可选 %s", c.Defaults.Format, strings.Join(names, "、")))
+	}
+	if c.Defaults.Level < 0 || c.Defaults.Level > 9 {
+		errs = append(errs, fmt.Errorf("  defaults.level: 压缩级别必须在 1-9 之间，0 表示默认: %d", c.Defaults.Level))
+	}
+	if c.Defaults.ExtractMode != "" && !slices.Contains(ExtractModes, c.Defaults.ExtractMode) {
+		errs = append(errs, fmt.Errorf("  defaults.extract_mode: 不支持的解压方式 %q，可选 %s", c.Defaults.ExtractMode, strings.Join(ExtractModes, "、")))
+	}
+	for _, field := range []struct{ key, value string }{
+		{"gzip_block_size", c.Defaults.GzipBlockSize},
+		{"zstd_window_size", c.Defaults.ZstdWindowSize},
+		{"zstd_max_memory", c.Defaults.ZstdMaxMemory},
+		{"zstd_frame_size", c.Defaults.ZstdFrameSize},
+	} {
+		if _, err := ParseSize(field.value); err != nil {
+			errs = append(errs, fmt.Errorf("  defaults.%s: %w", field.key, err))
+		}
+	}
+
+	var builtin []string
+	for _, cat := range GetExcludeCategories() {
+		builtin = append(builtin, cat.Name)
+	}
+	for _, name := range c.Excludes.Remove {
+		if !slices.Contains(builtin, name) {
+			errs = append(errs, fmt.Errorf("  excludes.remove: 没有名为 %q 的内置类别，可选 %s", name, strings.Join(builtin, "、")))
+		}
+	}
+	for _, name := range sortedKeys(c.Excludes.Rename) {
+		if !slices.Contains(builtin, name) {
+			errs = append(errs, fmt.Errorf("  excludes.rename: 没有名为 %q 的内置类别，可选 %s", name, strings.Join(builtin, "、")))
+		} else if c.Excludes.Rename[name] == "" {
+			errs = append(errs, fmt.Errorf("  excludes.rename: %q 的新名称不能为空", name))
+		}
+	}
+	for i, cat := range c.Excludes.Category {
+		if cat.Name == "" {
+			errs = append(errs, fmt.Errorf("  excludes.category[%d]: 缺少 name", i))
+		}
+		if len(cat.Patterns) == 0 {
+			errs = append(errs, fmt.Errorf("  excludes.category[%d]: 缺少 patterns", i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// DefaultFormatIndex 返回默认格式在 GetArchiveFormats 中的下标，未设置或无效时返回 -1
+// 格式可以写作 zip、.zip、ZIP、tar.gz 等
+func (c *Config) DefaultFormatIndex() int {
+	want := strings.ToLower(strings.TrimPrefix(c.Defaults.Format, "."))
+	if want == "" {
+		return -1
+	}
+	for i, f := range GetArchiveFormats() {
+		if strings.TrimPrefix(f.Extension, ".") == want || strings.ToLower(f.Name) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+// OutputDir 返回展开 ~ 后的默认输出目录
+func (c *Config) OutputDir() string {
+	return ExpandHome(c.Defaults.OutputDir)
+}
+
+// CompressNameTemplate 返回压缩输出命名模板
+func (c *Config) CompressNameTemplate() string {
+	if c.Defaults.NameTemplate == "" {
+		return DefaultOutputTemplate
+	}
+	return c.Defaults.NameTemplate
+}
+
+// ExtractNameTemplate 返回解压目录命名模板
+func (c *Config) ExtractNameTemplate() string {
+	if c.Defaults.ExtractTemplate == "" {
+		return DefaultExtractTemplate
+	}
+	return c.Defaults.ExtractTemplate
+}
+
+// ExtractLayout 返回默认的解压方式
+func (c *Config) ExtractLayout() string {
+	if c.Defaults.ExtractMode == "" {
+		return ExtractModeSmart
+	}
+	return c.Defaults.ExtractMode
+}
+
+// ExpandHome 将路径开头的 ~ 展开为用户主目录
+func ExpandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// ExcludeCategories 返回按配置调整后的内置类别、配置中的类别和保存的自定义类别
+func (c *Config) ExcludeCategories() ([]ExcludeCategory, error) {
+	var categories []ExcludeCategory
+	for _, cat := range GetExcludeCategories() {
+		if slices.Contains(c.Excludes.Remove, cat.Name) {
+			continue
+		}
+		if name, ok := c.Excludes.Rename[cat.Name]; ok {
+			cat.Name = name
+		}
+		categories = append(categories, cat)
+	}
+
+	for _, cat := range c.Excludes.Category {
+		selected := true
+		if cat.Selected != nil {
+			selected = *cat.Selected
+		}
+		categories = append(categories, ExcludeCategory{
+			Name:     cat.Name,
+			Patterns: cat.Patterns,
+			Selected: selected,
+		})
+	}
+
+	custom, err := LoadCustomCategories()
+	return append(categories, custom...), err
+}
+
+// sortedKeys 返回排序后的 map 键，保证错误信息顺序稳定
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
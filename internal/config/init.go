@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultTOML config init 生成的 TOML 配置
+const defaultTOML = `# SimpleArchiver 配置文件
+# 所有配置项都可以省略，省略时使用内置默认值
+
+# 界面语言：en / zh，留空跟随系统（LANG 等环境变量）
+language = ""
+
+# 界面主题：dark / light
+theme = "dark"
+
+# 覆盖主题颜色（#RRGGBB）
+# 可选：primary secondary success warning error muted foreground border archive
+[colors]
+# primary = "#7C3AED"
+
+[defaults]
+# 默认压缩格式：zip 7z tar.gz tar.bz2 tar.xz tar.zst tar.lz4
+format = "zip"
+# 压缩级别 1-9，0 使用各格式的默认级别
+level = 0
+# 压缩输出目录，留空时输出到源文件所在目录
+output_dir = ""
+# 输出命名模板，可用变量：
+#   {name} 源文件名  {ext} 格式扩展名  {host} 主机名
+#   {date} / {date:2006-01-02}  {time} / {time:150405}（Go 时间格式）
+#   {git.branch} {git.commit} {git.short} 源目录所在仓库的分支和提交
+#   {counter} / {counter:3} 序号，从 1 开始递增直到文件不存在
+name_template = "{name}{ext}"
+# 解压目录命名模板，{name} 为去掉扩展名的归档名
+extract_template = "{name}"
+# 解压方式：
+#   smart  归档只有一个顶层目录时保留其名称直接解压，避免 project/project 双层目录；否则放入新文件夹
+#   folder 始终解压到以归档命名的新文件夹
+#   here   直接解压到归档所在目录
+extract_mode = "smart"
+# 批量压缩、解压时同时执行的任务数，0 使用默认值（CPU 核数，最多 4 个）
+batch_workers = 0
+# 单个压缩、解压任务使用的协程数（ZIP、Gzip、XZ、Bzip2、Zstd 压缩和 ZIP、7z、XZ、Bzip2、Zstd 解压），0 使用全部 CPU 核，1 为串行
+workers = 0
+# TAR.GZ 并行压缩的块大小（如 4MB），块越大压缩率越高、内存占用越多，为空使用 1MB
+gzip_block_size = ""
+# 压缩 TAR.GZ 时同时建立随机访问索引（归档旁的 .gzidx 文件），列出条目和只解压部分条目时不必解压整个归档
+# 已有的归档可以运行 simple-archiver index <归档> 建立；归档被修改后索引自动失效
+# 建立索引需要单线程解压一遍输出（约 100MB/s），多核并行压缩会被限制在这个速度，大归档可以压缩后再运行 index
+gzip_index = false
+# Zstd 窗口大小，1KB-512MB 之间的 2 的幂（如 64MB），为空由压缩级别决定；超过 128MB 时 zstd 命令行解压需要 --long
+zstd_window_size = ""
+# Zstd 长距离模式，未设置 zstd_window_size 时使用 128MB 窗口，适合重复内容相距很远的大型目录
+zstd_long = false
+# 解压 Zstd 时允许的最大窗口（如 256MB），超过时报错而不是占用更多内存，为空使用默认值（512MB）
+zstd_max_memory = ""
+# 从待压缩文件中抽样训练 Zstd 字典：embed 嵌入归档开头（解压时自动读取），sidecar 保存为归档旁的 .dict 文件（也可用 zstd -D 解压），为空不使用
+# 同时生成不使用字典的压缩流，只在字典使归档变小时保留；非空文件少于 8 个时不训练字典；完成页面显示两者的大小对比；不能与可复现模式同时使用
+zstd_dict = ""
+# TAR.ZST 写成可寻址格式：分成独立压缩的帧并附带寻址表和条目索引，解压时并行解码，列出条目和只解压部分条目时直接跳转
+# 普通 zstd 命令仍能解压；压缩率略低于连续流；与 zstd_dict 同时使用时每帧都用字典压缩，帧较小时收益更明显
+zstd_seekable = false
+# 可寻址格式每帧的未压缩大小（64KB-256MB，如 4MB），帧越大压缩率越高、随机读取越慢，为空使用 1MB
+zstd_frame_size = ""
+
+[excludes]
+# 移除内置的排除类别（按名称）
+remove = []
+
+# 重命名内置类别
+[excludes.rename]
+# "Go 依赖" = "vendor 目录"
+
+# 新增排除类别，模式使用 gitignore 语法
+# [[excludes.category]]
+# name = "媒体文件"
+# patterns = ["*.mp4", "*.mov"]
+# selected = false
+`
+
+// defaultYAML config init --yaml 生成的 YAML 配置
+const defaultYAML = `# SimpleArchiver 配置文件
+# 所有配置项都可以省略，省略时使用内置默认值
+
+# 界面语言：en / zh，留空跟随系统（LANG 等环境变量）
+language: ""
+
+# 界面主题：dark / light
+theme: dark
+
+# 覆盖主题颜色（#RRGGBB）
+# 可选：primary secondary success warning error muted foreground border archive
+colors: {}
+#  primary: "#7C3AED"
+
+defaults:
+  # 默认压缩格式：zip 7z tar.gz tar.bz2 tar.xz tar.zst tar.lz4
+  format: zip
+  # 压缩级别 1-9，0 使用各格式的默认级别
+  level: 0
+  # 压缩输出目录，留空时输出到源文件所在目录
+  output_dir: ""
+  # 输出命名模板，可用变量：
+  #   {name} 源文件名  {ext} 格式扩展名  {host} 主机名
+  #   {date} / {date:2006-01-02}  {time} / {time:150405}（Go 时间格式）
+  #   {git.branch} {git.commit} {git.short} 源目录所在仓库的分支和提交
+  #   {counter} / {counter:3} 序号，从 1 开始递增直到文件不存在
+  name_template: "{name}{ext}"
+  # 解压目录命名模板，{name} 为去掉扩展名的归档名
+  extract_template: "{name}"
+  # 解压方式：
+  #   smart  归档只有一个顶层目录时保留其名称直接解压，避免 project/project 双层目录；否则放入新文件夹
+  #   folder 始终解压到以归档命名的新文件夹
+  #   here   直接解压到归档所在目录
+  extract_mode: smart
+  # 批量压缩、解压时同时执行的任务数，0 使用默认值（CPU 核数，最多 4 个）
+  batch_workers: 0
+  # 单个压缩、解压任务使用的协程数（ZIP、Gzip、XZ、Bzip2、Zstd 压缩和 ZIP、7z、XZ、Bzip2、Zstd 解压），0 使用全部 CPU 核，1 为串行
+  workers: 0
+  # TAR.GZ 并行压缩的块大小（如 4MB），块越大压缩率越高、内存占用越多，为空使用 1MB
+  gzip_block_size: ""
+  # 压缩 TAR.GZ 时同时建立随机访问索引（归档旁的 .gzidx 文件），列出条目和只解压部分条目时不必解压整个归档
+  # 已有的归档可以运行 simple-archiver index <归档> 建立；归档被修改后索引自动失效
+  # 建立索引需要单线程解压一遍输出（约 100MB/s），多核并行压缩会被限制在这个速度，大归档可以压缩后再运行 index
+  gzip_index: false
+  # Zstd 窗口大小，1KB-512MB 之间的 2 的幂（如 64MB），为空由压缩级别决定；超过 128MB 时 zstd 命令行解压需要 --long
+  zstd_window_size: ""
+  # Zstd 长距离模式，未设置 zstd_window_size 时使用 128MB 窗口，适合重复内容相距很远的大型目录
+  zstd_long: false
+  # 解压 Zstd 时允许的最大窗口（如 256MB），超过时报错而不是占用更多内存，为空使用默认值（512MB）
+  zstd_max_memory: ""
+  # 从待压缩文件中抽样训练 Zstd 字典：embed 嵌入归档开头（解压时自动读取），sidecar 保存为归档旁的 .dict 文件（也可用 zstd -D 解压），为空不使用
+  # 同时生成不使用字典的压缩流，只在字典使归档变小时保留；非空文件少于 8 个时不训练字典；完成页面显示两者的大小对比；不能与可复现模式同时使用
+  zstd_dict: ""
+  # TAR.ZST 写成可寻址格式：分成独立压缩的帧并附带寻址表和条目索引，解压时并行解码，列出条目和只解压部分条目时直接跳转
+  # 普通 zstd 命令仍能解压；压缩率略低于连续流；与 zstd_dict 同时使用时每帧都用字典压缩，帧较小时收益更明显
+  zstd_seekable: false
+  # 可寻址格式每帧的未压缩大小（64KB-256MB，如 4MB），帧越大压缩率越高、随机读取越慢，为空使用 1MB
+  zstd_frame_size: ""
+
+excludes:
+  # 移除内置的排除类别（按名称）
+  remove: []
+  # 重命名内置类别
+  rename: {}
+  #  "Go 依赖": vendor 目录
+  # 新增排除类别，模式使用 gitignore 语法
+  category: []
+  #  - name: 媒体文件
+  #    patterns: ["*.mp4", "*.mov"]
+  #    selected: false
+`
+
+// WriteDefault 在配置目录写入带注释的默认配置文件，返回文件路径
+// 文件已存在时需要 force 才会覆盖
+func WriteDefault(useYAML, force bool) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", fmt.Errorf("获取配置目录失败: %w", err)
+	}
+
+	name, content := "config.toml", defaultTOML
+	if useYAML {
+		name, content = "config.yaml", defaultYAML
+	}
+	path := filepath.Join(dir, name)
+
+	if !force {
+		for _, existing := range configFileNames {
+			if _, err := os.Stat(filepath.Join(dir, existing)); err == nil {
+				return "", fmt.Errorf("配置文件已存在: %s（使用 --force 覆盖）", filepath.Join(dir, existing))
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// presetsFile 保存预设的文件
+const presetsFile = "presets.toml"
+
+// 默认的输出命名模板
+const (
+	DefaultOutputTemplate  = "{name}{ext}" // 压缩输出
+	DefaultExtractTemplate = "{name}"      // 解压目录
+)
+
+// presetsDoc presets.toml 的结构
+type presetsDoc struct {
+	Preset []Preset `toml:"preset"`
+}
+
+// Preset 保存的压缩任务，密码不会保存
+type Preset struct {
+	Name   string `toml:"name"`
+	Source string `toml:"source,omitempty"`
+	Format string `toml:"format"` // 压缩格式，如 zip、tar.zst
+	// Sources 多个源路径，放入同一个归档，与 Source 只能设置一个
+	Sources []string `toml:"sources,omitempty"`
+	// Output 输出路径模板（如 {name}-{date}{ext}），留空使用配置中的 name_template
+	// 相对路径相对于配置中的 output_dir，未配置时相对于源文件所在目录
+	Output string `toml:"output,omitempty"`
+	Level  int    `toml:"level,omitzero"`
+
+	Excludes           []string `toml:"excludes,omitempty"`
+	Includes           []string `toml:"includes,omitempty"`
+	RespectIgnoreFiles bool     `toml:"respect_ignore_files,omitempty"`
+
+	MinSize    string   `toml:"min_size,omitempty"`    // 如 10KB、1.5MB
+	MaxSize    string   `toml:"max_size,omitempty"`    // 如 100MB
+	NewerThan  string   `toml:"newer_than,omitempty"`  // 相对时间（如 7d、12h）或日期（2006-01-02）
+	OlderThan  string   `toml:"older_than,omitempty"`  // 同 NewerThan
+	FileTypes  []string `toml:"file_types,omitempty"`  // regular、symlink
+	SkipBinary bool     `toml:"skip_binary,omitempty"` // 跳过二进制文件
+
+	Reproducible bool   `toml:"reproducible,omitempty"`
+	TarFormat    string `toml:"tar_format,omitempty"` // ustar、pax、gnu，留空自动选择
+
+	// ArchivePrefix 归档中的根目录名，留空使用源目录名，"." 表示不带根目录
+	ArchivePrefix string `toml:"archive_prefix,omitempty"`
+}
+
+// presetsPath 返回预设文件路径
+func presetsPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", fmt.Errorf("获取配置目录失败: %w", err)
+	}
+	return filepath.Join(dir, presetsFile), nil
+}
+
+// LoadPresets 读取保存的预设，文件不存在时返回空列表
+func LoadPresets() ([]Preset, error) {
+	path, err := presetsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var doc presetsDoc
+	meta, err := toml.DecodeFile(path, &doc)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取预设失败 %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("预设文件 %s 包含未知的配置项: %s", path, strings.Join(keys, ", "))
+	}
+
+	var errs []error
+	for i := range doc.Preset {
+		if err := doc.Preset[i].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("  preset[%d] %q:\n%w", i, doc.Preset[i].Name, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("预设文件 %s 无效:\n%w", path, err)
+	}
+	return doc.Preset, nil
+}
+
+// FindPreset 按名称查找预设
+func FindPreset(name string) (Preset, error) {
+	presets, err := LoadPresets()
+	if err != nil {
+		return Preset{}, err
+	}
+	var names []string
+	for _, p := range presets {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	if len(names) == 0 {
+		return Preset{}, fmt.Errorf("没有名为 %q 的预设，尚未保存任何预设", name)
+	}
+	return Preset{}, fmt.Errorf("没有名为 %q 的预设，可选 %s", name, strings.Join(names, "、"))
+}
+
+// SavePreset 保存预设，同名预设会被覆盖
+func SavePreset(preset Preset) error {
+	if err := preset.Validate(); err != nil {
+		return fmt.Errorf("预设无效:\n%w", err)
+	}
+
+	presets, err := LoadPresets()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range presets {
+		if presets[i].Name == preset.Name {
+			presets[i] = preset
+			replaced = true
+		}
+	}
+	if !replaced {
+		presets = append(presets, preset)
+	}
+
+	path, err := presetsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+
+	if err := writeTOML(path, presetsDoc{Preset: presets}); err != nil {
+		return fmt.Errorf("保存预设失败: %w", err)
+	}
+	return nil
+}
+
+// Validate 校验预设取值，返回所有问题
+func (p *Preset) Validate() error {
+	var errs []error
+
+	if strings.TrimSpace(p.Name) == "" {
+		errs = append(errs, fmt.Errorf("    name: 预设名称不能为空"))
+	}
+	switch {
+	case p.Source == "" && len(p.Sources) == 0:
+		errs = append(errs, fmt.Errorf("    source: 缺少源路径"))
+	case p.Source != "" && len(p.Sources) > 0:
+		errs = append(errs, fmt.Errorf("    sources: source 与 sources 只能设置一个"))
+	}
+	if p.FormatIndex() < 0 {
+		errs = append(errs, fmt.Errorf("    format: 不支持的格式 %q", p.Format))
+	}
+	if p.Level < 0 || p.Level > 9 {
+		errs = append(errs, fmt.Errorf("    level: 压缩级别必须在 1-9 之间，0 表示默认: %d", p.Level))
+	}
+	for _, field := range []struct{ key, value string }{{"min_size", p.MinSize}, {"max_size", p.MaxSize}} {
+		if _, err := ParseSize(field.value); err != nil {
+			errs = append(errs, fmt.Errorf("    %s: %w", field.key, err))
+		}
+	}
+	for _, field := range []struct{ key, value string }{{"newer_than", p.NewerThan}, {"older_than", p.OlderThan}} {
+		if _, err := ParseTime(field.value, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("    %s: %w", field.key, err))
+		}
+	}
+	for _, t := range p.FileTypes {
+		if t != "regular" && t != "symlink" {
+			errs = append(errs, fmt.Errorf("    file_types: 未知的文件类型 %q，可选 regular、symlink", t))
+		}
+	}
+	switch strings.ToLower(p.TarFormat) {
+	case "", "ustar", "pax", "gnu":
+	default:
+		errs = append(errs, fmt.Errorf("    tar_format: 不支持的 TAR 格式 %q，可选 ustar、pax、gnu", p.TarFormat))
+	}
+
+	return errors.Join(errs...)
+}
+
+// SourceList 返回预设的所有源路径
+func (p *Preset) SourceList() []string {
+	if len(p.Sources) > 0 {
+		return p.Sources
+	}
+	return []string{p.Source}
+}
+
+// FormatIndex 返回预设格式在 GetArchiveFormats 中的下标，无效时返回 -1
+func (p *Preset) FormatIndex() int {
+	return (&Config{Defaults: Defaults{Format: p.Format}}).DefaultFormatIndex()
+}
+
+// sizeUnits 文件大小单位
+var sizeUnits = map[string]int64{
+	"":   1,
+	"B":  1,
+	"K":  1 << 10,
+	"KB": 1 << 10,
+	"M":  1 << 20,
+	"MB": 1 << 20,
+	"G":  1 << 30,
+	"GB": 1 << 30,
+	"T":  1 << 40,
+	"TB": 1 << 40,
+}
+
+// ParseSize 解析文件大小，如 512、10KB、1.5M，空字符串返回 0
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	number, unit := s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	multiplier, ok := sizeUnits[unit]
+	value, err := strconv.ParseFloat(number, 64)
+	if !ok || err != nil || value < 0 {
+		return 0, fmt.Errorf("无效的文件大小 %q，示例：512、10KB、1.5MB", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// ParseTime 解析相对时间（如 30m、12h、7d、2w，相对于 now）或日期（2006-01-02），空字符串返回零值
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	units := map[byte]time.Duration{
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+	if unit, ok := units[s[len(s)-1]]; ok {
+		if n, err := strconv.Atoi(s[:len(s)-1]); err == nil && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无效的时间 %q，示例：12h、7d、2w、2006-01-02", s)
+}
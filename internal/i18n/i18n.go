@@ -42,6 +42,19 @@ type Messages struct {
 	HintPassword string
 	HintInput    string
 	HintExit     string
+	HintAddPattern   string
+	HintSaveCategory string
+	HintPattern      string
+	HintSavePreset   string
+	HintEditOutput   string
+	HintComplete     string
+	HintBrowse       string
+	HintCurrentDir   string
+	HintMark         string
+	HintContinue     string
+	HintMarked       string
+	HintMarkAll      string
+	HintBatch        string
 
 	// 模式选择
 	SelectModeTitle       string
@@ -49,12 +62,24 @@ type Messages struct {
 	CompressOptionDesc    string
 	ExtractOption         string
 	ExtractOptionDesc     string
+	PresetOption          string
+	PresetOptionDesc      string
+
+	// 预设
+	SelectPresetTitle     string
+	NoPresets             string
+	PresetLabel           string
+	SavePresetTitle       string
+	SavePresetHint        string
+	PresetNameEmpty       string
+	PresetSaved           string
 
 	// 文件选择
 	SelectFileCompress    string
 	SelectFileExtract     string
 	EmptyDir              string
 	ShowRange             string
+	MoreSources           string
 
 	// 格式选择
 	SelectFormat          string
@@ -64,6 +89,23 @@ type Messages struct {
 	SelectExcludes        string
 	ExcludeFormat         string
 	ToggleHint            string
+	ScanningExcludes      string
+	NoExcludesFound       string
+	DetectedProjects      string
+	CustomCategory        string
+	AddPatternTitle       string
+	AddPatternHint        string
+	PatternCounting       string
+	PatternMatches        string
+	SaveCategoryTitle     string
+	CategoryNameEmpty     string
+
+	// 文件名编码
+	SelectCharset         string
+	CharsetDetected       string
+	CharsetAuto           string
+	CharsetPreview        string
+	FilenameCharset       string
 
 	// 密码输入
 	PasswordTitle         string
@@ -84,6 +126,8 @@ type Messages struct {
 	SourceFile            string
 	OutputFile            string
 	ExtractTo             string
+	ExtractModeLabel      string
+	OutputTemplate        string
 	ExtractPassword       string
 	PasswordSet           string
 	PasswordNone          string
@@ -95,6 +139,15 @@ type Messages struct {
 	ConfirmStart          string
 	ConfirmStartExtract   string
 
+	// 输出位置
+	EditOutputTitle       string
+	EditOutputHint        string
+	ExtractSmart          string
+	ExtractIntoFolder     string
+	ExtractHere           string
+	OutputEmpty           string
+	SelectOutputDir       string
+
 	// 压缩中/解压中
 	Compressing           string
 	Extracting            string
@@ -113,13 +166,37 @@ type Messages struct {
 	ExtractDone           string
 	OutputFileLabel       string
 	ExtractToLabel        string
+	FlattenedDir          string
 	ExtractedFiles        string
 	ExtractedSize         string
+	RenamedEntries        string
+	RenamedMore           string
+	RenameNFC             string
+	RenameSanitize        string
+	RenameCollision       string
 	CompressedFiles       string
 	OriginalSize          string
 	CompressedSize        string
 	CompressionRate       string
 	ExcludedFiles         string
+	ZstdDictStat          string
+	ZstdDictUsed          string
+	ZstdDictDiscarded     string
+	ZstdDictFewSamples    string
+	GzipIndexStat         string
+
+	// 批量任务
+	NothingMarked         string
+	BatchCompressTitle    string
+	BatchExtractTitle     string
+	BatchJobs             string
+	BatchWorkers          string
+	BatchCompressing      string
+	BatchExtracting       string
+	BatchSummary          string
+	BatchDone             string
+	BatchDoneFailed       string
+	BatchFailedJobs       string
 
 	// 错误
 	CompressFailed        string
@@ -134,38 +211,79 @@ var messagesEN = Messages{
 	ModeCompress: "Compress",
 	ModeExtract:  "Extract",
 
-	HintUp:        "Up",
-	HintDown:      "Down",
-	HintEnter:     "Enter",
-	HintSelect:    "Select",
-	HintBack:      "Back",
-	HintQuit:      "Quit",
-	HintToggle:    "Toggle",
-	HintSelectAll: "All",
-	HintClear:     "Clear",
-	HintConfirm:   "Confirm",
-	HintCancel:    "Cancel",
-	HintPassword:  "Password",
-	HintInput:     "Input",
-	HintExit:      "Exit",
+	HintUp:           "Up",
+	HintDown:         "Down",
+	HintEnter:        "Enter",
+	HintSelect:       "Select",
+	HintBack:         "Back",
+	HintQuit:         "Quit",
+	HintToggle:       "Toggle",
+	HintSelectAll:    "All",
+	HintClear:        "Clear",
+	HintConfirm:      "Confirm",
+	HintCancel:       "Cancel",
+	HintPassword:     "Password",
+	HintInput:        "Input",
+	HintExit:         "Exit",
+	HintAddPattern:   "Add pattern",
+	HintSaveCategory: "Save",
+	HintPattern:      "Pattern",
+	HintSavePreset:   "Save preset",
+	HintEditOutput:   "Output",
+	HintComplete:     "Complete",
+	HintBrowse:       "Browse",
+	HintCurrentDir:   "This dir",
+	HintMark:         "Mark",
+	HintContinue:     "Continue",
+	HintMarked:       "marked",
+	HintMarkAll:      "Mark all",
+	HintBatch:        "One each",
 
 	SelectModeTitle:    "🎯 Select Operation Mode",
 	CompressOption:     "Compress File/Folder",
 	CompressOptionDesc: "Compress files or folders into an archive",
 	ExtractOption:      "Extract Archive",
 	ExtractOptionDesc:  "Extract archive to a directory",
+	PresetOption:       "Run Preset",
+	PresetOptionDesc:   "Run a saved compression job",
+
+	SelectPresetTitle: "⭐ Select Preset",
+	NoPresets:         "No presets saved yet. Press P on the confirm screen to save one.",
+	PresetLabel:       "Preset:",
+	SavePresetTitle:   "💾 Save as Preset",
+	SavePresetHint:    "Source, format, excludes and output are saved; passwords are not",
+	PresetNameEmpty:   "Preset name cannot be empty",
+	PresetSaved:       "Saved preset %q",
 
 	SelectFileCompress: "📂 Select File or Folder to Compress",
 	SelectFileExtract:  "📂 Select Archive to Extract",
 	EmptyDir:           "(empty directory)",
 	ShowRange:          "Showing %d-%d / %d",
+	MoreSources:        " and %d more",
 
 	SelectFormat: "📦 Select Compression Format",
 	SelectedFile: "Selected: ",
 
-	SelectExcludes: "🚫 Select Exclude Rules",
-	ExcludeFormat:  "Format: ",
-	ToggleHint:     " | Space to toggle",
+	SelectExcludes:   "🚫 Select Exclude Rules",
+	ExcludeFormat:    "Format: ",
+	ToggleHint:       " | Space to toggle",
+	ScanningExcludes: "Scanning for excludable items...",
+	NoExcludesFound:  "Nothing to exclude was found",
+	DetectedProjects: "Detected project: %s",
+
+	CustomCategory:    "Custom",
+	AddPatternTitle:   "➕ Add Exclude Pattern",
+	AddPatternHint:    "gitignore syntax, e.g. *.mp4, data/raw, !keep.log",
+	PatternCounting:   "Counting matches...",
+	PatternMatches:    "Matches %d file(s) (%s)",
+	SaveCategoryTitle: "💾 Save as Category",
+	CategoryNameEmpty: "Category name cannot be empty",
+
+	SelectCharset:   "🔤 Select Filename Encoding",
+	CharsetDetected: "Non-UTF-8 filenames found, detected: %s",
+	CharsetAuto:     "Auto detect",
+	CharsetPreview:  "Preview:",
+	FilenameCharset: "Encoding:",
 
 	PasswordTitle:       "🔐 Password Protection",
 	PasswordExtract:     "🔐 Enter Extraction Password",
@@ -184,6 +302,8 @@ var messagesEN = Messages{
 	SourceFile:          "Source:",
 	OutputFile:          "Output:",
 	ExtractTo:           "Extract to:",
+	ExtractModeLabel:    "Layout:",
+	OutputTemplate:      "Template:",
 	ExtractPassword:     "Password:",
 	PasswordSet:         "🔑 Set",
 	PasswordNone:        "🔓 None",
@@ -195,6 +315,14 @@ var messagesEN = Messages{
 	ConfirmStart:        "Press Y/Enter to start compression, N/Esc to go back",
 	ConfirmStartExtract: "Press Y/Enter to start extraction, N/Esc to go back",
 
+	EditOutputTitle:   "📝 Output Destination",
+	EditOutputHint:    "Relative paths are resolved from the source directory",
+	ExtractSmart:      "Smart (no double-nested folder)",
+	ExtractIntoFolder: "Extract into new folder",
+	ExtractHere:       "Extract here",
+	OutputEmpty:       "Output path cannot be empty",
+	SelectOutputDir:   "📂 Select Output Directory",
+
 	Compressing:   "🚀 Compressing...",
 	Extracting:    "📂 Extracting...",
 	Preparing:     "Preparing...",
@@ -211,14 +339,38 @@ var messagesEN = Messages{
 	ExtractDone:     "🎉 Extraction Complete!",
 	OutputFileLabel: "Output:",
 	ExtractToLabel:  "Extracted to:",
+	FlattenedDir:    "Single top-level folder %s/ was kept as is instead of nesting it",
 	ExtractedFiles:  "Files:",
 	ExtractedSize:   "Size:",
+	RenamedEntries:  "Renamed:",
+	RenamedMore:     "... and %d more",
+	RenameNFC:       "Unicode NFC",
+	RenameSanitize:  "invalid name",
+	RenameCollision: "case collision",
 	CompressedFiles: "Files:",
 	OriginalSize:    "Original:",
 	CompressedSize:  "Compressed:",
 	CompressionRate: "Ratio:",
 	ExcludedFiles:   "Excluded:",
 
+	ZstdDictStat:       "Zstd dict:",
+	ZstdDictUsed:       "used",
+	ZstdDictDiscarded:  "not used, no gain",
+	ZstdDictFewSamples: "not used, only %d sample file(s)",
+	GzipIndexStat:      "Gzip index:",
+
+	NothingMarked:      "Mark items with Space first",
+	BatchCompressTitle: "✅ Confirm Batch Compression (one archive each)",
+	BatchExtractTitle:  "✅ Confirm Batch Extraction",
+	BatchJobs:          "Jobs:",
+	BatchWorkers:       "Parallel:",
+	BatchCompressing:   "🚀 Batch Compressing...",
+	BatchExtracting:    "🚀 Batch Extracting...",
+	BatchSummary:       "%d done, %d failed, %d canceled / %d",
+	BatchDone:          "✅ Batch Finished",
+	BatchDoneFailed:    "⚠️ Batch Finished, %d job(s) failed",
+	BatchFailedJobs:    "Failed:",
+
 	CompressFailed: "❌ Compression Failed",
 	ExtractFailed:  "❌ Extraction Failed",
 	ErrorMessage:   "Error:",
@@ -231,38 +383,79 @@ var messagesZH = Messages{
 	ModeCompress: "压缩",
 	ModeExtract:  "解压",
 
-	HintUp:        "上移",
-	HintDown:      "下移",
-	HintEnter:     "进入",
-	HintSelect:    "选择",
-	HintBack:      "返回",
-	HintQuit:      "退出",
-	HintToggle:    "切换",
-	HintSelectAll: "全选",
-	HintClear:     "清除",
-	HintConfirm:   "确认",
-	HintCancel:    "取消",
-	HintPassword:  "密码",
-	HintInput:     "输入",
-	HintExit:      "退出",
+	HintUp:           "上移",
+	HintDown:         "下移",
+	HintEnter:        "进入",
+	HintSelect:       "选择",
+	HintBack:         "返回",
+	HintQuit:         "退出",
+	HintToggle:       "切换",
+	HintSelectAll:    "全选",
+	HintClear:        "清除",
+	HintConfirm:      "确认",
+	HintCancel:       "取消",
+	HintPassword:     "密码",
+	HintInput:        "输入",
+	HintExit:         "退出",
+	HintAddPattern:   "添加模式",
+	HintSaveCategory: "保存",
+	HintPattern:      "模式",
+	HintSavePreset:   "保存预设",
+	HintEditOutput:   "输出位置",
+	HintComplete:     "补全",
+	HintBrowse:       "浏览",
+	HintCurrentDir:   "当前目录",
+	HintMark:         "标记",
+	HintContinue:     "继续",
+	HintMarked:       "项已标记",
+	HintMarkAll:      "全部标记",
+	HintBatch:        "逐个压缩",
 
 	SelectModeTitle:    "🎯 选择操作模式",
 	CompressOption:     "压缩文件/文件夹",
 	CompressOptionDesc: "将文件或文件夹压缩为归档文件",
 	ExtractOption:      "解压归档文件",
 	ExtractOptionDesc:  "将压缩包解压到指定目录",
+	PresetOption:       "运行预设",
+	PresetOptionDesc:   "运行保存的压缩任务",
+
+	SelectPresetTitle: "⭐ 选择预设",
+	NoPresets:         "尚未保存预设，可在确认页面按 P 保存",
+	PresetLabel:       "预设:",
+	SavePresetTitle:   "💾 保存为预设",
+	SavePresetHint:    "将保存源路径、格式、排除规则和输出位置，不保存密码",
+	PresetNameEmpty:   "预设名称不能为空",
+	PresetSaved:       "已保存预设 %q",
 
 	SelectFileCompress: "📂 选择要压缩的文件或文件夹",
 	SelectFileExtract:  "📂 选择要解压的归档文件",
 	EmptyDir:           "(空目录)",
 	ShowRange:          "显示 %d-%d / %d",
+	MoreSources:        " 等 %d 项",
 
 	SelectFormat: "📦 选择压缩格式",
 	SelectedFile: "已选择: ",
 
-	SelectExcludes: "🚫 选择排除规则",
-	ExcludeFormat:  "格式: ",
-	ToggleHint:     " | 空格切换选中状态",
+	SelectExcludes:   "🚫 选择排除规则",
+	ExcludeFormat:    "格式: ",
+	ToggleHint:       " | 空格切换选中状态",
+	ScanningExcludes: "正在扫描可排除的内容...",
+	NoExcludesFound:  "未找到可排除的内容",
+	DetectedProjects: "检测到项目类型: %s",
+
+	CustomCategory:    "自定义规则",
+	AddPatternTitle:   "➕ 添加排除模式",
+	AddPatternHint:    "gitignore 语法，如 *.mp4、data/raw、!keep.log",
+	PatternCounting:   "正在统计匹配的文件...",
+	PatternMatches:    "匹配 %d 个文件（%s）",
+	SaveCategoryTitle: "💾 保存为类别",
+	CategoryNameEmpty: "类别名称不能为空",
+
+	SelectCharset:   "🔤 选择文件名编码",
+	CharsetDetected: "检测到非 UTF-8 文件名，推测编码: %s",
+	CharsetAuto:     "自动检测",
+	CharsetPreview:  "文件名预览:",
+	FilenameCharset: "文件名编码:",
 
 	PasswordTitle:       "🔐 密码保护设置",
 	PasswordExtract:     "🔐 输入解压密码",
@@ -281,6 +474,8 @@ var messagesZH = Messages{
 	SourceFile:          "源文件:",
 	OutputFile:          "输出文件:",
 	ExtractTo:           "解压到:",
+	ExtractModeLabel:    "解压方式:",
+	OutputTemplate:      "命名模板:",
 	ExtractPassword:     "解压密码:",
 	PasswordSet:         "🔑 已设置",
 	PasswordNone:        "🔓 无",
@@ -292,6 +487,14 @@ var messagesZH = Messages{
 	ConfirmStart:        "按 Y/Enter 开始压缩，N/Esc 返回修改",
 	ConfirmStartExtract: "按 Y/Enter 开始解压，N/Esc 返回修改",
 
+	EditOutputTitle:   "📝 输出位置",
+	EditOutputHint:    "相对路径以源文件所在目录为基准",
+	ExtractSmart:      "智能解压（避免双层目录）",
+	ExtractIntoFolder: "解压到新文件夹",
+	ExtractHere:       "解压到此处",
+	OutputEmpty:       "输出路径不能为空",
+	SelectOutputDir:   "📂 选择输出目录",
+
 	Compressing:   "🚀 正在压缩...",
 	Extracting:    "📂 正在解压...",
 	Preparing:     "准备中...",
@@ -308,14 +511,38 @@ var messagesZH = Messages{
 	ExtractDone:     "🎉 解压完成！",
 	OutputFileLabel: "输出文件:",
 	ExtractToLabel:  "解压到:",
+	FlattenedDir:    "归档只有一个顶层目录 %s/，已保留原名称直接解压，没有再套一层",
 	ExtractedFiles:  "解压文件:",
 	ExtractedSize:   "解压大小:",
+	RenamedEntries:  "重命名:",
+	RenamedMore:     "... 另有 %d 项",
+	RenameNFC:       "Unicode 规范化",
+	RenameSanitize:  "非法文件名",
+	RenameCollision: "大小写冲突",
 	CompressedFiles: "压缩文件:",
 	OriginalSize:    "原始大小:",
 	CompressedSize:  "压缩后大小:",
 	CompressionRate: "压缩率:",
 	ExcludedFiles:   "排除文件:",
 
+	ZstdDictStat:       "Zstd 字典:",
+	ZstdDictUsed:       "已使用",
+	ZstdDictDiscarded:  "没有收益，未使用",
+	ZstdDictFewSamples: "样本文件只有 %d 个，未使用",
+	GzipIndexStat:      "Gzip 索引:",
+
+	NothingMarked:      "请先用空格标记条目",
+	BatchCompressTitle: "✅ 确认批量压缩（每项单独压缩）",
+	BatchExtractTitle:  "✅ 确认批量解压",
+	BatchJobs:          "任务数:",
+	BatchWorkers:       "并发数:",
+	BatchCompressing:   "🚀 正在批量压缩...",
+	BatchExtracting:    "🚀 正在批量解压...",
+	BatchSummary:       "完成 %d，失败 %d，取消 %d / 共 %d",
+	BatchDone:          "✅ 批量任务完成",
+	BatchDoneFailed:    "⚠️ 批量任务完成，%d 个失败",
+	BatchFailedJobs:    "失败的任务:",
+
 	CompressFailed: "❌ 压缩失败",
 	ExtractFailed:  "❌ 解压失败",
 	ErrorMessage:   "错误信息:",
@@ -7,8 +7,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/progress"
@@ -94,88 +98,116 @@ var (
 
 // 样式定义
 var (
+	titleStyle           lipgloss.Style
+	subtitleStyle        lipgloss.Style
+	borderStyle          lipgloss.Style
+	highlightBorderStyle lipgloss.Style
+	selectedStyle        lipgloss.Style
+	normalStyle          lipgloss.Style
+	disabledStyle        lipgloss.Style
+	successStyle         lipgloss.Style
+	errorStyle           lipgloss.Style
+	warningStyle         lipgloss.Style
+	infoStyle            lipgloss.Style
+	statLabelStyle       lipgloss.Style
+	statValueStyle       lipgloss.Style
+	helpStyle            lipgloss.Style
+	folderIconStyle      lipgloss.Style
+	fileIconStyle        lipgloss.Style
+	archiveIconStyle     lipgloss.Style
+)
+
+// initStyles 按当前配色创建样式，切换主题后需要重新调用
+func initStyles() {
 	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(primaryColor).
-			MarginBottom(1)
+		Bold(true).
+		Foreground(primaryColor).
+		MarginBottom(1)
 
 	subtitleStyle = lipgloss.NewStyle().
-			Foreground(mutedColor).
-			Italic(true)
+		Foreground(mutedColor).
+		Italic(true)
 
 	borderStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(borderColor).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(1, 2)
 
 	highlightBorderStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(primaryColor).
-				Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(primaryColor).
+		Padding(1, 2)
 
 	selectedStyle = lipgloss.NewStyle().
-			Foreground(foregroundColor).
-			Background(primaryColor).
-			Bold(true).
-			Padding(0, 1)
+		Foreground(foregroundColor).
+		Background(primaryColor).
+		Bold(true).
+		Padding(0, 1)
 
 	normalStyle = lipgloss.NewStyle().
-			Foreground(foregroundColor).
-			Padding(0, 1)
+		Foreground(foregroundColor).
+		Padding(0, 1)
 
 	disabledStyle = lipgloss.NewStyle().
-			Foreground(mutedColor).
-			Strikethrough(true).
-			Padding(0, 1)
+		Foreground(mutedColor).
+		Strikethrough(true).
+		Padding(0, 1)
 
 	successStyle = lipgloss.NewStyle().
-			Foreground(successColor).
-			Bold(true)
+		Foreground(successColor).
+		Bold(true)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(errorColor).
-			Bold(true)
+		Foreground(errorColor).
+		Bold(true)
 
 	warningStyle = lipgloss.NewStyle().
-			Foreground(warningColor)
+		Foreground(warningColor)
 
 	infoStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor)
+		Foreground(secondaryColor)
 
 	statLabelStyle = lipgloss.NewStyle().
-			Foreground(mutedColor).
-			Width(20)
+		Foreground(mutedColor).
+		Width(20)
 
 	statValueStyle = lipgloss.NewStyle().
-			Foreground(foregroundColor).
-			Bold(true)
+		Foreground(foregroundColor).
+		Bold(true)
 
 	helpStyle = lipgloss.NewStyle().
-			Foreground(mutedColor).
-			MarginTop(1)
+		Foreground(mutedColor).
+		MarginTop(1)
 
 	folderIconStyle = lipgloss.NewStyle().
-			Foreground(warningColor)
+		Foreground(warningColor)
 
 	fileIconStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor)
+		Foreground(secondaryColor)
 
 	archiveIconStyle = lipgloss.NewStyle().
-			Foreground(archiveColor)
-)
+		Foreground(archiveColor)
+}
 
 // AppState 应用状态
 type appState int
 
 const (
 	stateSelectMode appState = iota
+	stateSelectPreset
 	stateSelectFile
 	stateSelectFormat
 	stateSelectExcludes
+	stateInputPattern
+	stateSelectCharset
 	stateInputPassword
 	stateConfirm
+	stateInputPresetName
+	stateEditOutput
+	stateSelectOutputDir
 	stateCompressing
 	stateExtracting
+	stateBatchRunning
 	stateDone
 	stateError
 )
@@ -193,6 +225,7 @@ type fileEntry struct {
 type model struct {
 	state             appState
 	mode              opMode
+	cfg               *config.Config
 	modeCursor        int
 	entries           []fileEntry
 	cursor            int
@@ -204,15 +237,60 @@ type model struct {
 	formats           []config.ArchiveFormat
 	excludeCategories []config.ExcludeCategory
 	excludeCursor     int
-
-	selectedPath      string
+	excludeScanning   bool     // 正在扫描目录中可排除的内容
+	detectedProjects  []string // 根据标记文件检测到的项目类型
+	excludeWarning    string   // 读取自定义类别失败等提示
+
+	// 自定义排除模式
+	customCategory    int    // 本次会话中添加的自定义类别下标，-1 表示尚未添加
+	patternInput      string
+	patternError      string
+	patternMatch      *archiver.ExcludeMatch // 当前输入匹配的文件，nil 表示正在统计
+	namingCategory    bool                   // 正在输入保存的类别名称
+
+	// 预设
+	presets           []config.Preset
+	presetCursor      int
+	preset            *config.Preset           // 正在运行的预设，nil 表示手动选择
+	presetOpts        archiver.CompressOptions // 由预设生成的压缩选项
+	presetInput       string
+	presetError       string
+	presetSaved       string // 刚保存的预设名称
+
+	selectedPath      string   // 选择的文件，多选时为各个源的共同上级目录
+	selectedPaths     []string // 多选的源路径，为空时只压缩 selectedPath
+	marked            []string // 文件列表中已标记的条目（压缩模式）
+	selectError       string
 	selectedFormat    config.ArchiveFormat
+
+	// 批量任务
+	batch             bool                   // 批量模式：每个源单独压缩，或解压多个归档
+	batchSources      []string               // 批量任务的源路径或归档
+	batchOutputs      []string               // 每个任务的输出路径
+	batchRows         []archiver.JobUpdate   // 每个任务的最新状态
+	batchResults      []archiver.BatchResult
 	outputPath        string
+	outputTemplate    string // 生成 outputPath 的命名模板，手动修改后为空
+
+	// 输出位置编辑
+	outputInput       string
+	outputError       string
+	outputCandidates  []string // Tab 补全的候选项
+	extractMode       string   // 解压方式，见 config.ExtractModes
+	extractFolder     string   // 切换回新文件夹时使用的文件夹名
+	pickingDir        bool     // 文件列表只显示目录（选择输出目录）
+	pickerReturnCwd   string   // 选择输出目录前文件浏览器所在的目录
 	password          string
 	passwordInput     string
 	usePassword       bool
 	passwordCursor    int // 0: 不使用密码, 1: 使用密码
 
+	// ZIP 文件名编码（解压模式）
+	legacyNames       bool     // 归档中存在非 UTF-8 文件名
+	detectedCharset   string   // 自动检测到的编码
+	charsetCursor     int
+	charsetPreview    []string // 当前编码下的文件名预览
+
 	progress          progress.Model
 	spinner           spinner.Model
 	compressStats     archiver.CompressStats
@@ -262,6 +340,29 @@ type extractDoneMsg struct {
 	err   error
 }
 
+// excludeScanMsg 排除类别扫描完成消息
+type excludeScanMsg struct {
+	path       string
+	categories []config.ExcludeCategory
+	projects   []string
+	err        error
+	loadErr    error // 读取自定义类别失败，内置类别仍然可用
+}
+
+// patternCountMsg 自定义模式匹配统计消息
+type patternCountMsg struct {
+	pattern string
+	match   archiver.ExcludeMatch
+}
+
+// patternInputMsg 输入停顿后触发统计的消息，pattern 为输入时的模式
+type patternInputMsg struct {
+	pattern string
+}
+
+// patternCountDelay 输入停顿多久后才扫描目录统计匹配数
+const patternCountDelay = 300 * time.Millisecond
+
 // tickMsg 定时器消息
 type tickMsg time.Time
 
@@ -282,7 +383,7 @@ func listenProgressChan(ch chan interface{}) tea.Cmd {
 }
 
 // newModel 创建新的应用模型
-func newModel() model {
+func newModel(cfg *config.Config) model {
 	cwd, err := os.Getwd()
 	if err != nil {
 		cwd = "/"
@@ -304,11 +405,16 @@ func newModel() model {
 		cwd:               cwd,
 		formats:           config.GetArchiveFormats(),
 		excludeCategories: config.GetExcludeCategories(),
+		customCategory:    -1,
+		cfg:               cfg,
 		progress:          p,
 		spinner:           s,
 		width:             80,
 		height:            24,
 	}
+	if i := cfg.DefaultFormatIndex(); i >= 0 {
+		m.formatCursor = i
+	}
 	return m
 }
 
@@ -329,6 +435,10 @@ func (m *model) loadEntries() {
 		if strings.HasPrefix(entry.Name(), ".") {
 			continue
 		}
+		// 选择输出目录时只显示目录
+		if m.pickingDir && !entry.IsDir() {
+			continue
+		}
 
 		info, err := entry.Info()
 		if err != nil {
@@ -400,22 +510,65 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch m.state {
 		case stateSelectMode:
 			return m.updateSelectMode(msg)
+		case stateSelectPreset:
+			return m.updateSelectPreset(msg)
 		case stateSelectFile:
 			return m.updateSelectFile(msg)
 		case stateSelectFormat:
 			return m.updateSelectFormat(msg)
 		case stateSelectExcludes:
 			return m.updateSelectExcludes(msg)
+		case stateInputPattern:
+			return m.updateInputPattern(msg)
+		case stateSelectCharset:
+			return m.updateSelectCharset(msg)
 		case stateInputPassword:
 			return m.updateInputPassword(msg)
 		case stateConfirm:
 			return m.updateConfirm(msg)
+		case stateInputPresetName:
+			return m.updateInputPresetName(msg)
+		case stateEditOutput:
+			return m.updateEditOutput(msg)
+		case stateSelectOutputDir:
+			return m.updateSelectOutputDir(msg)
 		case stateDone, stateError:
 			if key.Matches(msg, key.NewBinding(key.WithKeys("q", "esc", "enter"))) {
 				return m, tea.Quit
 			}
 		}
 
+	case excludeScanMsg:
+		// 忽略已切换目录后返回的旧结果
+		if msg.path == m.selectedPath && m.excludeScanning {
+			m.excludeScanning = false
+			if msg.err != nil {
+				// 扫描失败时退回完整的类别列表
+				m.excludeCategories, _ = m.cfg.ExcludeCategories()
+				m.detectedProjects = nil
+			} else {
+				m.excludeCategories = msg.categories
+				m.detectedProjects = msg.projects
+			}
+			m.excludeWarning = ""
+			if msg.loadErr != nil {
+				m.excludeWarning = msg.loadErr.Error()
+			}
+		}
+
+	case patternInputMsg:
+		// 停顿期间又有输入时放弃本次统计
+		if m.state == stateInputPattern && !m.namingCategory && msg.pattern == m.patternInput {
+			cmds = append(cmds, countPattern(m.selectedPath, msg.pattern))
+		}
+
+	case patternCountMsg:
+		// 只接受与当前输入一致的统计结果
+		if m.state == stateInputPattern && !m.namingCategory && msg.pattern == m.patternInput {
+			match := msg.match
+			m.patternMatch = &match
+		}
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -456,9 +609,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					cmds = append(cmds, m.progress.SetPercent(percent))
 				}
 				m.extractStats = v.stats
+			case batchProgressMsg:
+				m.applyJobUpdate(archiver.JobUpdate(v))
 			}
 			// 继续监听通道
-			if m.progressChan != nil && (m.state == stateCompressing || m.state == stateExtracting) {
+			if m.progressChan != nil && (m.state == stateCompressing || m.state == stateExtracting || m.state == stateBatchRunning) {
 				cmds = append(cmds, listenProgressChan(m.progressChan))
 			}
 		}
@@ -482,11 +637,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = stateDone
 			if msg.stats != nil {
 				m.extractStats = *msg.stats
+				if msg.stats.Output != "" {
+					m.outputPath = msg.stats.Output
+				}
 			}
 		}
 
+	case batchDoneMsg:
+		m.batchResults = msg.results
+		m.state = stateDone
+
 	case tickMsg:
-		if m.state == stateCompressing || m.state == stateExtracting {
+		if m.state == stateCompressing || m.state == stateExtracting || m.state == stateBatchRunning {
 			// 计算速度
 			m.updateSpeed()
 			cmds = append(cmds, tea.Tick(200*time.Millisecond, func(t time.Time) tea.Msg {
@@ -510,16 +672,30 @@ func (m model) updateSelectMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "down", "j":
-		if m.modeCursor < 1 {
+		if m.modeCursor < 2 {
 			m.modeCursor++
 		}
 
 	case "enter", " ":
-		if m.modeCursor == 0 {
+		switch m.modeCursor {
+		case 0:
 			m.mode = modeCompress
-		} else {
+		case 1:
 			m.mode = modeExtract
+		default:
+			presets, err := config.LoadPresets()
+			if err != nil {
+				m.state = stateError
+				m.errorMsg = err.Error()
+				return m, nil
+			}
+			m.mode = modeCompress
+			m.presets = presets
+			m.presetCursor = 0
+			m.state = stateSelectPreset
+			return m, nil
 		}
+		m.preset = nil
 		m.state = stateSelectFile
 		m.loadEntries()
 	}
@@ -527,10 +703,79 @@ func (m model) updateSelectMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateSelectPreset 更新预设选择状态
+func (m model) updateSelectPreset(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.state = stateSelectMode
+
+	case "up", "k":
+		if m.presetCursor > 0 {
+			m.presetCursor--
+		}
+
+	case "down", "j":
+		if m.presetCursor < len(m.presets)-1 {
+			m.presetCursor++
+		}
+
+	case "enter", " ":
+		if len(m.presets) == 0 {
+			return m, nil
+		}
+		preset := m.presets[m.presetCursor]
+		opts, err := presetOptions(preset, m.cfg, time.Now())
+		if err != nil {
+			m.state = stateError
+			m.errorMsg = err.Error()
+			return m, nil
+		}
+
+		m.preset = &preset
+		m.presetOpts = opts
+		m.batch = false
+		m.presetSaved = ""
+		m.selectedPath = opts.Source
+		m.selectedPaths = opts.Sources
+		if len(opts.Sources) > 0 {
+			m.selectedPath = commonDir(opts.Sources)
+		}
+		m.selectedFormat = m.formats[preset.FormatIndex()]
+		m.outputPath = opts.Output
+		m.outputTemplate = preset.Output
+		if m.outputTemplate == "" {
+			m.outputTemplate = m.cfg.CompressNameTemplate()
+		}
+		m.password = ""
+		m.passwordInput = ""
+		m.usePassword = false
+
+		// 7z 格式需要 7z 命令
+		if opts.Format == ".7z" && !archiver.Is7zAvailable() {
+			m.state = stateError
+			m.errorMsg = "7z command not found. Please install p7zip:\n  - Ubuntu/Debian: sudo apt install p7zip-full\n  - macOS: brew install p7zip\n  - Windows: Download from https://www.7-zip.org/"
+			return m, nil
+		}
+
+		// 预设不保存密码，ZIP 格式需要重新选择
+		if opts.Format == ".zip" {
+			m.passwordCursor = 0
+			m.state = stateInputPassword
+		} else {
+			m.state = stateConfirm
+		}
+	}
+
+	return m, nil
+}
+
 // updateSelectFile 更新文件选择状态
 func (m model) updateSelectFile(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.selectError = ""
+
 	switch msg.String() {
 	case "q", "esc":
+		m.marked = nil
 		m.state = stateSelectMode
 
 	case "up", "k":
@@ -544,9 +789,15 @@ func (m model) updateSelectFile(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "enter", "l":
-		if len(m.entries) > 0 && m.entries[m.cursor].isDir {
-			m.cwd = m.entries[m.cursor].path
-			m.loadEntries()
+		if len(m.entries) > 0 {
+			entry := m.entries[m.cursor]
+			if entry.isDir {
+				m.cwd = entry.path
+				m.loadEntries()
+			} else if m.mode == modeExtract && entry.isArchive {
+				// 解压模式：直接选择光标所在的归档
+				return m.selectArchive(entry.path)
+			}
 		}
 
 	case "backspace", "h":
@@ -557,46 +808,207 @@ func (m model) updateSelectFile(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case " ":
+		// 标记条目，可以跨目录选择多个；解压模式只能标记归档
 		if len(m.entries) > 0 {
 			entry := m.entries[m.cursor]
-			m.selectedPath = entry.path
-
-			if m.mode == modeExtract {
-				// 解压模式：只能选择压缩文件
-				if entry.isArchive {
-					// 自动生成解压目录名
-					baseName := filepath.Base(entry.path)
-					// 移除所有扩展名
-					for {
-						ext := filepath.Ext(baseName)
-						if ext == "" || (!strings.HasPrefix(ext, ".tar") && ext != ".zip" && ext != ".gz" && ext != ".bz2" && ext != ".xz" && ext != ".zst" && ext != ".lz4" && ext != ".tgz" && ext != ".tbz2" && ext != ".txz" && ext != ".7z") {
-							break
-						}
-						baseName = strings.TrimSuffix(baseName, ext)
-					}
-					m.outputPath = filepath.Join(filepath.Dir(entry.path), baseName)
-					
-					// 检测是否是支持密码的格式（ZIP或7z）
-					format := archiver.DetectArchiveFormat(entry.path)
-					if format == ".zip" || format == ".7z" {
-						// 进入密码输入界面
-						m.state = stateInputPassword
-						m.passwordCursor = 0
-						m.passwordInput = ""
-					} else {
-						m.state = stateConfirm
-					}
-				}
+			if m.mode == modeExtract && !entry.isArchive {
+				return m, nil
+			}
+			if i := slices.Index(m.marked, entry.path); i >= 0 {
+				m.marked = slices.Delete(m.marked, i, i+1)
 			} else {
-				// 压缩模式
-				m.state = stateSelectFormat
+				m.marked = append(m.marked, entry.path)
 			}
 		}
+
+	case "a":
+		m.markAll()
+
+	case "c":
+		return m.confirmSources()
+
+	case "b":
+		// 压缩模式：每个标记的条目单独压缩
+		if m.mode == modeCompress {
+			return m.selectBatch(m.marked)
+		}
+	}
+
+	return m, nil
+}
+
+// markAll 标记当前目录中的所有条目（解压模式只标记归档），已全部标记时取消标记
+func (m *model) markAll() {
+	var paths []string
+	for _, entry := range m.entries {
+		if m.mode == modeExtract && !entry.isArchive {
+			continue
+		}
+		paths = append(paths, entry.path)
+	}
+
+	allMarked := true
+	for _, path := range paths {
+		if !slices.Contains(m.marked, path) {
+			allMarked = false
+			m.marked = append(m.marked, path)
+		}
+	}
+	if allMarked {
+		m.marked = slices.DeleteFunc(m.marked, func(path string) bool {
+			return slices.Contains(paths, path)
+		})
+	}
+}
+
+// selectArchive 选择要解压的归档，生成解压目录后进入编码、密码或确认页面
+func (m model) selectArchive(path string) (tea.Model, tea.Cmd) {
+	m.selectedPath = path
+	m.selectedPaths = nil
+	m.batch = false
+
+	// 自动生成解压目录名
+	baseName := archiveBaseName(path)
+	output, err := outputFromTemplate(m.cfg.ExtractNameTemplate(), filepath.Dir(path), path, baseName, strings.TrimPrefix(filepath.Base(path), baseName))
+	if err != nil {
+		m.state = stateError
+		m.errorMsg = err.Error()
+		return m, nil
+	}
+	m.outputPath = output
+	m.outputTemplate = m.cfg.ExtractNameTemplate()
+	m.extractFolder = filepath.Base(output)
+	m.extractMode = m.cfg.ExtractLayout()
+	if m.extractMode == config.ExtractModeHere {
+		m.outputPath = filepath.Dir(output)
+	}
+
+	// ZIP 文件名不是 UTF-8 时先选择编码
+	format := archiver.DetectArchiveFormat(path)
+	m.legacyNames = false
+	m.charsetCursor = 0
+	if format == ".zip" {
+		charset, legacy, err := archiver.DetectZipCharset(path)
+		if err == nil && legacy {
+			m.legacyNames = true
+			m.detectedCharset = charset
+			m.loadCharsetPreview()
+			m.state = stateSelectCharset
+			return m, nil
+		}
+	}
+
+	// 检测是否是支持密码的格式（ZIP或7z）
+	if format == ".zip" || format == ".7z" {
+		// 进入密码输入界面
+		m.state = stateInputPassword
+		m.passwordCursor = 0
+		m.passwordInput = ""
+	} else {
+		m.state = stateConfirm
+	}
+	return m, nil
+}
+
+// archiveBaseName 返回去掉所有归档扩展名的文件名，如 project.tar.gz → project
+func archiveBaseName(path string) string {
+	baseName := filepath.Base(path)
+	for {
+		ext := filepath.Ext(baseName)
+		if ext == "" || (!strings.HasPrefix(ext, ".tar") && ext != ".zip" && ext != ".gz" && ext != ".bz2" && ext != ".xz" && ext != ".zst" && ext != ".lz4" && ext != ".tgz" && ext != ".tbz2" && ext != ".txz" && ext != ".7z") {
+			break
+		}
+		baseName = strings.TrimSuffix(baseName, ext)
+	}
+	return baseName
+}
+
+// confirmSources 确认标记的条目，没有标记时使用光标所在的条目
+// 压缩模式将所有条目放入同一个归档；解压模式标记了多个归档时批量解压
+func (m model) confirmSources() (tea.Model, tea.Cmd) {
+	sources := m.marked
+	if len(sources) == 0 {
+		if len(m.entries) == 0 {
+			return m, nil
+		}
+		entry := m.entries[m.cursor]
+		if m.mode == modeExtract && !entry.isArchive {
+			return m, nil
+		}
+		sources = []string{entry.path}
+	}
+
+	if m.mode == modeExtract {
+		if len(sources) == 1 {
+			return m.selectArchive(sources[0])
+		}
+		return m.selectBatch(sources)
 	}
 
+	if err := archiver.CheckSources(sources); err != nil {
+		m.selectError = err.Error()
+		return m, nil
+	}
+
+	m.batch = false
+	m.selectedPath = sources[0]
+	m.selectedPaths = nil
+	if len(sources) > 1 {
+		m.selectedPath = commonDir(sources)
+		m.selectedPaths = slices.Clone(sources)
+	}
+	m.state = stateSelectFormat
 	return m, nil
 }
 
+// commonDir 返回多个路径共同的上级目录
+func commonDir(paths []string) string {
+	dir := filepath.Dir(paths[0])
+	for _, path := range paths[1:] {
+		for {
+			rel, err := filepath.Rel(dir, path)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	return dir
+}
+
+// sourceDir 返回源所在的目录，多选时为各个源的共同上级目录
+func (m model) sourceDir() string {
+	if len(m.selectedPaths) > 0 {
+		return m.selectedPath
+	}
+	return filepath.Dir(m.selectedPath)
+}
+
+// sourceLabel 返回源的显示名称，多选时列出前几个源的名称
+func (m model) sourceLabel() string {
+	if len(m.selectedPaths) == 0 {
+		return filepath.Base(m.selectedPath)
+	}
+
+	const shown = 3
+	var names []string
+	for _, path := range m.selectedPaths {
+		if len(names) == shown {
+			break
+		}
+		names = append(names, filepath.Base(path))
+	}
+	label := strings.Join(names, ", ")
+	if len(m.selectedPaths) > shown {
+		label += fmt.Sprintf(i18n.T().MoreSources, len(m.selectedPaths)-shown)
+	}
+	return label
+}
+
 // updateSelectFormat 更新格式选择状态
 func (m model) updateSelectFormat(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -623,15 +1035,87 @@ func (m model) updateSelectFormat(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		m.outputPath = m.selectedPath + m.selectedFormat.Extension
+		if m.batch {
+			// 批量压缩：每个源单独生成输出路径
+			if err := m.planBatchOutputs(); err != nil {
+				m.state = stateError
+				m.errorMsg = err.Error()
+				return m, nil
+			}
+		} else {
+			dir := m.cfg.OutputDir()
+			if dir == "" {
+				dir = m.sourceDir()
+			}
+			output, err := outputFromTemplate(m.cfg.CompressNameTemplate(), dir, m.selectedPath, filepath.Base(m.selectedPath), m.selectedFormat.Extension)
+			if err != nil {
+				m.state = stateError
+				m.errorMsg = err.Error()
+				return m, nil
+			}
+			m.outputPath = output
+		}
+		m.outputTemplate = m.cfg.CompressNameTemplate()
 		m.state = stateSelectExcludes
+		m.excludeScanning = true
+		m.excludeCursor = 0
+		m.customCategory = -1
+		return m, scanExcludes(m.selectedPath, m.cfg)
 	}
 
 	return m, nil
 }
 
+// outputFromTemplate 按命名模板生成输出路径，相对路径相对于 dir
+func outputFromTemplate(template, dir, source, name, ext string) (string, error) {
+	vars := archiver.NewOutputVars(source, name, ext, time.Now())
+	return archiver.ResolveOutputPath(config.ExpandHome(template), dir, vars)
+}
+
+// scanExcludes 在后台扫描目录，只保留实际存在的排除类别
+func scanExcludes(path string, cfg *config.Config) tea.Cmd {
+	return func() tea.Msg {
+		categories, loadErr := cfg.ExcludeCategories()
+		groups := make([]archiver.ExcludeGroup, len(categories))
+		for i, cat := range categories {
+			groups[i] = archiver.ExcludeGroup{Patterns: cat.Patterns, IgnoreFiles: cat.IgnoreFiles}
+		}
+		markers := make([]string, 0, len(config.ProjectMarkers))
+		for marker := range config.ProjectMarkers {
+			markers = append(markers, marker)
+		}
+
+		results, found, err := archiver.ScanExcludes(path, groups, markers)
+		if err != nil {
+			return excludeScanMsg{path: path, err: err}
+		}
+
+		matches := make([][]string, len(results))
+		sizes := make([]int64, len(results))
+		for i, r := range results {
+			matches[i] = r.Paths
+			sizes[i] = r.Size
+		}
+		projects := config.DetectProjects(found)
+		return excludeScanMsg{
+			path:       path,
+			categories: config.ApplyScan(categories, matches, sizes, projects),
+			projects:   projects,
+			loadErr:    loadErr,
+		}
+	}
+}
+
 // updateSelectExcludes 更新排除规则选择状态
 func (m model) updateSelectExcludes(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.excludeScanning {
+		if msg.String() == "q" || msg.String() == "esc" {
+			m.excludeScanning = false
+			m.state = stateSelectFormat
+		}
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "q", "esc":
 		m.state = stateSelectFormat
@@ -647,7 +1131,9 @@ func (m model) updateSelectExcludes(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case " ":
-		m.excludeCategories[m.excludeCursor].Selected = !m.excludeCategories[m.excludeCursor].Selected
+		if len(m.excludeCategories) > 0 {
+			m.excludeCategories[m.excludeCursor].Selected = !m.excludeCategories[m.excludeCursor].Selected
+		}
 
 	case "a":
 		for i := range m.excludeCategories {
@@ -659,6 +1145,22 @@ func (m model) updateSelectExcludes(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.excludeCategories[i].Selected = false
 		}
 
+	case "+":
+		m.state = stateInputPattern
+		m.namingCategory = false
+		m.patternInput = ""
+		m.patternError = ""
+		m.patternMatch = nil
+
+	case "s":
+		// 将本次添加的自定义规则保存为命名类别
+		if m.customCategory >= 0 && m.excludeCursor == m.customCategory {
+			m.state = stateInputPattern
+			m.namingCategory = true
+			m.patternInput = ""
+			m.patternError = ""
+		}
+
 	case "enter":
 		// 如果是ZIP格式，询问是否加密
 		if m.selectedFormat.Extension == ".zip" {
@@ -672,13 +1174,159 @@ func (m model) updateSelectExcludes(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateInputPattern 更新自定义排除模式输入状态
+func (m model) updateInputPattern(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = stateSelectExcludes
+		return m, nil
+
+	case tea.KeyEnter:
+		if m.namingCategory {
+			return m.saveCustomCategory()
+		}
+		return m.addCustomPattern()
+
+	case tea.KeyBackspace:
+		if runes := []rune(m.patternInput); len(runes) > 0 {
+			m.patternInput = string(runes[:len(runes)-1])
+		}
+
+	case tea.KeyRunes, tea.KeySpace:
+		m.patternInput += string(msg.Runes)
+
+	default:
+		return m, nil
+	}
+
+	if m.namingCategory {
+		m.patternError = ""
+		return m, nil
+	}
+
+	// 实时校验，输入停顿后再统计匹配的文件
+	m.patternMatch = nil
+	if err := archiver.ValidatePattern(m.patternInput); err != nil {
+		m.patternError = err.Error()
+		return m, nil
+	}
+	m.patternError = ""
+	pattern := m.patternInput
+	return m, tea.Tick(patternCountDelay, func(time.Time) tea.Msg {
+		return patternInputMsg{pattern: pattern}
+	})
+}
+
+// countPattern 在后台统计模式匹配的文件
+func countPattern(path, pattern string) tea.Cmd {
+	return func() tea.Msg {
+		results, _, err := archiver.ScanExcludes(path, []archiver.ExcludeGroup{{Patterns: []string{pattern}}}, nil)
+		if err != nil || len(results) == 0 {
+			return patternCountMsg{pattern: pattern}
+		}
+		return patternCountMsg{pattern: pattern, match: results[0]}
+	}
+}
+
+// addCustomPattern 将输入的模式加入本次会话的自定义类别
+func (m model) addCustomPattern() (tea.Model, tea.Cmd) {
+	if err := archiver.ValidatePattern(m.patternInput); err != nil {
+		m.patternError = err.Error()
+		return m, nil
+	}
+
+	if m.customCategory < 0 {
+		m.excludeCategories = append(m.excludeCategories, config.ExcludeCategory{
+			Name:     i18n.T().CustomCategory,
+			Selected: true,
+			Custom:   true,
+		})
+		m.customCategory = len(m.excludeCategories) - 1
+	}
+
+	cat := &m.excludeCategories[m.customCategory]
+	cat.Patterns = append(cat.Patterns, strings.TrimSpace(m.patternInput))
+	cat.Selected = true
+	if m.patternMatch != nil {
+		cat.Matches = append(cat.Matches, m.patternMatch.Paths...)
+		cat.Size += m.patternMatch.Size
+	}
+
+	m.excludeCursor = m.customCategory
+	m.state = stateSelectExcludes
+	return m, nil
+}
+
+// saveCustomCategory 将自定义类别以输入的名称保存到配置目录
+func (m model) saveCustomCategory() (tea.Model, tea.Cmd) {
+	name := strings.TrimSpace(m.patternInput)
+	if name == "" {
+		m.patternError = i18n.T().CategoryNameEmpty
+		return m, nil
+	}
+
+	cat := m.excludeCategories[m.customCategory]
+	cat.Name = name
+	if err := config.SaveCustomCategory(cat); err != nil {
+		m.patternError = err.Error()
+		return m, nil
+	}
+
+	// 保存后再添加的模式进入新的自定义类别
+	m.excludeCategories[m.customCategory] = cat
+	m.customCategory = -1
+	m.state = stateSelectExcludes
+	return m, nil
+}
+
+// updateSelectCharset 更新文件名编码选择状态
+func (m model) updateSelectCharset(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.state = stateSelectFile
+
+	case "up", "k":
+		if m.charsetCursor > 0 {
+			m.charsetCursor--
+			m.loadCharsetPreview()
+		}
+
+	case "down", "j":
+		if m.charsetCursor < len(archiver.ZipCharsets)-1 {
+			m.charsetCursor++
+			m.loadCharsetPreview()
+		}
+
+	case "enter", " ":
+		m.state = stateInputPassword
+		m.passwordCursor = 0
+		m.passwordInput = ""
+	}
+
+	return m, nil
+}
+
+// loadCharsetPreview 按当前选中的编码加载文件名预览
+func (m *model) loadCharsetPreview() {
+	preview, err := archiver.PreviewZipNames(m.selectedPath, archiver.ZipCharsets[m.charsetCursor], 8)
+	if err != nil {
+		m.charsetPreview = nil
+		return
+	}
+	m.charsetPreview = preview
+}
+
 // updateInputPassword 更新密码输入状态
 func (m model) updateInputPassword(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// 解压模式：简化的密码输入（只有输入密码选项）
 	if m.mode == modeExtract {
 		switch msg.String() {
 		case "q", "esc":
-			m.state = stateSelectFile
+			if m.legacyNames {
+				m.state = stateSelectCharset
+			} else {
+				m.state = stateSelectFile
+			}
 			m.passwordInput = ""
 			m.password = ""
 
@@ -705,6 +1353,9 @@ func (m model) updateInputPassword(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "q", "esc":
 		m.state = stateSelectExcludes
+		if m.preset != nil {
+			m.state = stateSelectPreset
+		}
 		m.passwordInput = ""
 		m.usePassword = false
 
@@ -752,6 +1403,10 @@ func (m model) updateInputPassword(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // updateConfirm 更新确认状态
 func (m model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.batch {
+		return m.updateBatchConfirm(msg)
+	}
+
 	switch msg.String() {
 	case "q", "esc", "n":
 		if m.mode == modeExtract {
@@ -764,9 +1419,30 @@ func (m model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		} else if m.selectedFormat.Extension == ".zip" {
 			m.state = stateInputPassword
+		} else if m.preset != nil {
+			m.state = stateSelectPreset
 		} else {
 			m.state = stateSelectExcludes
 		}
+		m.presetSaved = ""
+
+	case "p":
+		// 将当前选择保存为预设
+		if m.mode == modeCompress {
+			m.presetInput = ""
+			m.presetError = ""
+			m.state = stateInputPresetName
+		}
+
+	case "o":
+		// 修改输出位置
+		m.outputInput = m.outputPath
+		m.outputError = ""
+		m.outputCandidates = nil
+		if m.extractMode != config.ExtractModeHere {
+			m.extractFolder = filepath.Base(m.outputPath)
+		}
+		m.state = stateEditOutput
 
 	case "y", "enter":
 		// 初始化速度统计
@@ -799,6 +1475,304 @@ func (m model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateEditOutput 更新输出位置编辑状态
+func (m model) updateEditOutput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = stateConfirm
+		return m, nil
+
+	case tea.KeyEnter:
+		return m.applyOutputInput()
+
+	case tea.KeyTab:
+		m.outputInput, m.outputCandidates = completePath(m.outputInput, m.mode == modeExtract)
+		m.outputError = ""
+		return m, nil
+
+	case tea.KeyCtrlO:
+		// 打开目录选择器
+		dir := nearestDir(m.expandOutputInput())
+		if m.extractMode != config.ExtractModeHere && dir == m.expandOutputInput() {
+			dir = filepath.Dir(dir)
+		}
+		m.pickerReturnCwd = m.cwd
+		m.cwd = dir
+		m.pickingDir = true
+		m.loadEntries()
+		m.state = stateSelectOutputDir
+		return m, nil
+
+	case tea.KeyUp, tea.KeyDown:
+		// 解压：切换解压方式
+		if m.mode != modeExtract {
+			return m, nil
+		}
+		i := 0
+		for j, mode := range config.ExtractModes {
+			if mode == m.extractMode {
+				i = j
+			}
+		}
+		if msg.Type == tea.KeyUp && i > 0 {
+			i--
+		} else if msg.Type == tea.KeyDown && i < len(config.ExtractModes)-1 {
+			i++
+		}
+		mode := config.ExtractModes[i]
+
+		// 解压到此处时输入的是目标目录，其他方式输入的是新文件夹
+		wasHere, here := m.extractMode == config.ExtractModeHere, mode == config.ExtractModeHere
+		input := strings.TrimRight(m.outputInput, string(filepath.Separator))
+		if here && !wasHere {
+			m.extractFolder = filepath.Base(input)
+			m.outputInput = filepath.Dir(input)
+		} else if wasHere && !here {
+			m.outputInput = filepath.Join(input, m.extractFolder)
+		}
+		m.extractMode = mode
+
+	case tea.KeyBackspace:
+		if runes := []rune(m.outputInput); len(runes) > 0 {
+			m.outputInput = string(runes[:len(runes)-1])
+		}
+
+	case tea.KeyRunes, tea.KeySpace:
+		m.outputInput += string(msg.Runes)
+
+	default:
+		return m, nil
+	}
+
+	m.outputError = ""
+	m.outputCandidates = nil
+	return m, nil
+}
+
+// expandOutputInput 返回输入的输出路径的绝对路径，相对路径相对于源文件所在目录
+func (m model) expandOutputInput() string {
+	path := config.ExpandHome(strings.TrimSpace(m.outputInput))
+	if path != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(m.sourceDir(), path)
+	}
+	return filepath.Clean(path)
+}
+
+// applyOutputInput 检查输入的输出位置是否可写，可写时返回确认页面
+func (m model) applyOutputInput() (tea.Model, tea.Cmd) {
+	if strings.TrimSpace(m.outputInput) == "" {
+		m.outputError = i18n.T().OutputEmpty
+		return m, nil
+	}
+
+	path := m.expandOutputInput()
+	if err := archiver.CheckWritable(path, m.mode == modeExtract); err != nil {
+		m.outputError = err.Error()
+		return m, nil
+	}
+
+	if path != m.outputPath {
+		m.outputPath = path
+		m.outputTemplate = ""
+	}
+	m.state = stateConfirm
+	return m, nil
+}
+
+// nearestDir 返回 path 本身或最近的已存在上级目录
+func nearestDir(path string) string {
+	for {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// completePath 补全输入的路径，返回补全结果和有多个匹配时的候选项
+func completePath(input string, dirsOnly bool) (string, []string) {
+	dirPart, prefix := filepath.Split(input)
+	dir := config.ExpandHome(dirPart)
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return input, nil
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (dirsOnly && !entry.IsDir()) {
+			continue
+		}
+		// 只有明确输入 . 时才补全隐藏文件
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		matches = append(matches, name)
+	}
+
+	switch len(matches) {
+	case 0:
+		return input, nil
+	case 1:
+		return dirPart + matches[0], nil
+	}
+
+	// 多个匹配时补全到公共前缀
+	common := matches[0]
+	for _, match := range matches[1:] {
+		for !strings.HasPrefix(match, common) {
+			_, size := utf8.DecodeLastRuneInString(common)
+			common = common[:len(common)-size]
+		}
+	}
+	return dirPart + common, matches
+}
+
+// updateSelectOutputDir 更新输出目录选择状态
+func (m model) updateSelectOutputDir(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		return m.closeDirPicker(""), nil
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < len(m.entries)-1 {
+			m.cursor++
+		}
+
+	case "enter", "l":
+		if len(m.entries) > 0 {
+			m.cwd = m.entries[m.cursor].path
+			m.loadEntries()
+		}
+
+	case "backspace", "h":
+		parent := filepath.Dir(m.cwd)
+		if parent != m.cwd {
+			m.cwd = parent
+			m.loadEntries()
+		}
+
+	case " ":
+		if len(m.entries) > 0 {
+			return m.closeDirPicker(m.entries[m.cursor].path), nil
+		}
+
+	case ".":
+		return m.closeDirPicker(m.cwd), nil
+	}
+
+	return m, nil
+}
+
+// closeDirPicker 关闭目录选择器，dir 非空时将其作为输出目录
+func (m model) closeDirPicker(dir string) model {
+	if dir != "" {
+		if m.mode == modeExtract && m.extractMode == config.ExtractModeHere {
+			m.outputInput = dir
+		} else {
+			name := filepath.Base(strings.TrimRight(m.outputInput, string(filepath.Separator)))
+			m.outputInput = filepath.Join(dir, name)
+		}
+		m.outputError = ""
+		m.outputCandidates = nil
+	}
+
+	m.pickingDir = false
+	m.cwd = m.pickerReturnCwd
+	m.loadEntries()
+	m.state = stateEditOutput
+	return m
+}
+
+// updateInputPresetName 更新预设名称输入状态
+func (m model) updateInputPresetName(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = stateConfirm
+
+	case tea.KeyEnter:
+		name := strings.TrimSpace(m.presetInput)
+		if name == "" {
+			m.presetError = i18n.T().PresetNameEmpty
+			return m, nil
+		}
+		if err := config.SavePreset(m.presetFromModel(name)); err != nil {
+			m.presetError = err.Error()
+			return m, nil
+		}
+		m.presetSaved = name
+		m.state = stateConfirm
+
+	case tea.KeyBackspace:
+		if runes := []rune(m.presetInput); len(runes) > 0 {
+			m.presetInput = string(runes[:len(runes)-1])
+		}
+		m.presetError = ""
+
+	case tea.KeyRunes, tea.KeySpace:
+		m.presetInput += string(msg.Runes)
+		m.presetError = ""
+	}
+
+	return m, nil
+}
+
+// selectedExcludes 收集选中类别的排除模式，忽略文件类别改为读取目录中的忽略文件
+func (m model) selectedExcludes() (excludes []string, respectIgnoreFiles bool) {
+	for _, cat := range m.excludeCategories {
+		if !cat.Selected {
+			continue
+		}
+		if cat.IgnoreFiles {
+			respectIgnoreFiles = true
+		} else {
+			excludes = append(excludes, cat.Patterns...)
+		}
+	}
+	return excludes, respectIgnoreFiles
+}
+
+// compressOptions 返回本次压缩的选项，运行预设时使用预设生成的选项
+func (m model) compressOptions() archiver.CompressOptions {
+	if m.preset != nil {
+		opts := m.presetOpts
+		opts.Output = m.outputPath
+		opts.Password = m.password
+		return opts
+	}
+
+	excludes, respectIgnoreFiles := m.selectedExcludes()
+	opts := archiver.CompressOptions{
+		Source:             m.selectedPath,
+		Sources:            m.selectedPaths,
+		Output:             m.outputPath,
+		Format:             m.selectedFormat.Extension,
+		Excludes:           excludes,
+		Password:           m.password,
+		Level:              m.cfg.Defaults.Level,
+		RespectIgnoreFiles: respectIgnoreFiles,
+	}
+	compressTuning(m.cfg, &opts)
+	return opts
+}
+
 // startCompress 开始压缩
 func (m *model) startCompress() tea.Cmd {
 	// 创建进度通道
@@ -809,39 +1783,26 @@ func (m *model) startCompress() tea.Cmd {
 	m.operationCtx = ctx
 	m.operationCancel = cancel
 
-	// 收集排除模式
-	var excludes []string
-	for _, cat := range m.excludeCategories {
-		if cat.Selected {
-			excludes = append(excludes, cat.Patterns...)
-		}
-	}
+	opts := m.compressOptions()
 
 	// 压缩任务
 	compressCmd := func() tea.Msg {
 		defer close(progressChan)
 
-		opts := archiver.CompressOptions{
-			Source:   m.selectedPath,
-			Output:   m.outputPath,
-			Format:   m.selectedFormat.Extension,
-			Excludes: excludes,
-			Password: m.password,
-			OnProgress: func(current, total int, currentFile string) {
-				// OnProgress 只用于简单进度更新，完整统计由 OnStats 处理
-			},
-			OnStats: func(stats archiver.CompressStats) {
-				// 发送完整统计信息到通道（非阻塞）
-				select {
-				case progressChan <- compressProgressMsg{
-					current:     stats.ProcessedFiles,
-					total:       stats.TotalFiles,
-					currentFile: stats.CurrentFile,
-					stats:       stats,
-				}:
-				default:
-				}
-			},
+		opts.OnProgress = func(current, total int, currentFile string) {
+			// OnProgress 只用于简单进度更新，完整统计由 OnStats 处理
+		}
+		opts.OnStats = func(stats archiver.CompressStats) {
+			// 发送完整统计信息到通道（非阻塞）
+			select {
+			case progressChan <- compressProgressMsg{
+				current:     stats.ProcessedFiles,
+				total:       stats.TotalFiles,
+				currentFile: stats.CurrentFile,
+				stats:       stats,
+			}:
+			default:
+			}
 		}
 
 		stats, err := archiver.Compress(ctx, opts)
@@ -877,6 +1838,12 @@ func (m *model) startExtract() tea.Cmd {
 			Source:   m.selectedPath,
 			Output:   m.outputPath,
 			Password: m.password,
+			Charset:  archiver.ZipCharsets[m.charsetCursor],
+			// 按当前系统规范化文件名，重命名会列在完成页面
+			NormalizeNFC:         true,
+			SanitizeFor:          runtime.GOOS,
+			DetectCaseCollisions: runtime.GOOS == "windows" || runtime.GOOS == "darwin",
+			Smart:                m.extractMode == config.ExtractModeSmart,
 			OnProgress: func(current, total int, currentFile string) {
 				// OnProgress 只用于简单进度更新，完整统计由 OnStats 处理
 			},
@@ -893,6 +1860,7 @@ func (m *model) startExtract() tea.Cmd {
 				}
 			},
 		}
+		extractTuning(m.cfg, &opts)
 
 		stats, err := archiver.Extract(ctx, opts)
 		if err != nil {
@@ -1031,15 +1999,28 @@ func (m model) renderStatusBar() string {
 			{"Enter", t.HintSelect},
 			{"q", t.HintQuit},
 		}
+	case stateSelectPreset:
+		hints = []keyHint{
+			{"↑/k", t.HintUp},
+			{"↓/j", t.HintDown},
+			{"Enter", t.HintSelect},
+			{"Esc", t.HintBack},
+		}
 	case stateSelectFile:
 		hints = []keyHint{
 			{"↑/k", t.HintUp},
 			{"↓/j", t.HintDown},
 			{"Enter/l", t.HintEnter},
 			{"h/BS", t.HintBack},
-			{"Space", t.HintSelect},
-			{"Esc", t.HintBack},
 		}
+		hints = append(hints, keyHint{"Space", t.HintMark}, keyHint{"a", t.HintMarkAll}, keyHint{"c", t.HintContinue})
+		if m.mode == modeCompress {
+			hints = append(hints, keyHint{"b", t.HintBatch})
+		}
+		if len(m.marked) > 0 {
+			hints = append(hints, keyHint{strconv.Itoa(len(m.marked)), t.HintMarked})
+		}
+		hints = append(hints, keyHint{"Esc", t.HintBack})
 	case stateSelectFormat:
 		hints = []keyHint{
 			{"↑/k", t.HintUp},
@@ -1054,6 +2035,22 @@ func (m model) renderStatusBar() string {
 			{"Space", t.HintToggle},
 			{"a", t.HintSelectAll},
 			{"n", t.HintClear},
+			{"+", t.HintAddPattern},
+		}
+		if m.customCategory >= 0 && m.excludeCursor == m.customCategory {
+			hints = append(hints, keyHint{"s", t.HintSaveCategory})
+		}
+		hints = append(hints, keyHint{"Enter", t.HintConfirm}, keyHint{"Esc", t.HintBack})
+	case stateInputPattern:
+		hints = []keyHint{
+			{t.HintInput, t.HintPattern},
+			{"Enter", t.HintConfirm},
+			{"Esc", t.HintCancel},
+		}
+	case stateSelectCharset:
+		hints = []keyHint{
+			{"↑/k", t.HintUp},
+			{"↓/j", t.HintDown},
 			{"Enter", t.HintConfirm},
 			{"Esc", t.HintBack},
 		}
@@ -1068,7 +2065,39 @@ func (m model) renderStatusBar() string {
 			{"y/Enter", t.HintConfirm},
 			{"n/Esc", t.HintBack},
 		}
-	case stateCompressing, stateExtracting:
+		if m.batch {
+			break
+		}
+		hints = append(hints, keyHint{"o", t.HintEditOutput})
+		if m.mode == modeCompress {
+			hints = append(hints, keyHint{"p", t.HintSavePreset})
+		}
+	case stateEditOutput:
+		hints = []keyHint{
+			{"Tab", t.HintComplete},
+			{"Ctrl+O", t.HintBrowse},
+		}
+		if m.mode == modeExtract {
+			hints = append(hints, keyHint{"↑/↓", t.HintToggle})
+		}
+		hints = append(hints, keyHint{"Enter", t.HintConfirm}, keyHint{"Esc", t.HintCancel})
+	case stateSelectOutputDir:
+		hints = []keyHint{
+			{"↑/k", t.HintUp},
+			{"↓/j", t.HintDown},
+			{"Enter/l", t.HintEnter},
+			{"h/BS", t.HintBack},
+			{"Space", t.HintSelect},
+			{".", t.HintCurrentDir},
+			{"Esc", t.HintCancel},
+		}
+	case stateInputPresetName:
+		hints = []keyHint{
+			{t.HintInput, t.HintSavePreset},
+			{"Enter", t.HintConfirm},
+			{"Esc", t.HintCancel},
+		}
+	case stateCompressing, stateExtracting, stateBatchRunning:
 		hints = []keyHint{
 			{"Ctrl+C", t.HintCancel},
 		}
@@ -1187,20 +2216,34 @@ func (m model) View() string {
 	switch m.state {
 	case stateSelectMode:
 		content = m.viewSelectMode()
+	case stateSelectPreset:
+		content = m.viewSelectPreset()
 	case stateSelectFile:
 		content = m.viewSelectFile()
 	case stateSelectFormat:
 		content = m.viewSelectFormat()
 	case stateSelectExcludes:
 		content = m.viewSelectExcludes()
+	case stateInputPattern:
+		content = m.viewInputPattern()
+	case stateSelectCharset:
+		content = m.viewSelectCharset()
 	case stateInputPassword:
 		content = m.viewInputPassword()
 	case stateConfirm:
 		content = m.viewConfirm()
+	case stateInputPresetName:
+		content = m.viewInputPresetName()
+	case stateEditOutput:
+		content = m.viewEditOutput()
+	case stateSelectOutputDir:
+		content = m.viewSelectFile()
 	case stateCompressing:
 		content = m.viewCompressing()
 	case stateExtracting:
 		content = m.viewExtracting()
+	case stateBatchRunning:
+		content = m.viewBatchRunning()
 	case stateDone:
 		content = m.viewDone()
 	case stateError:
@@ -1258,6 +2301,7 @@ func (m model) viewSelectMode() string {
 	}{
 		{iconCompress, primaryColor, t.CompressOption, t.CompressOptionDesc},
 		{iconFolderOpen, successColor, t.ExtractOption, t.ExtractOptionDesc},
+		{iconRocket, warningColor, t.PresetOption, t.PresetOptionDesc},
 	}
 
 	for i, mode := range modes {
@@ -1283,12 +2327,41 @@ func (m model) viewSelectMode() string {
 	return borderStyle.Render(sb.String())
 }
 
+// viewSelectPreset 渲染预设选择视图
+func (m model) viewSelectPreset() string {
+	t := i18n.T()
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render(t.SelectPresetTitle))
+	sb.WriteString("\n\n")
+
+	if len(m.presets) == 0 {
+		sb.WriteString(subtitleStyle.Render(t.NoPresets))
+		return borderStyle.Render(sb.String())
+	}
+
+	for i, preset := range m.presets {
+		cursor := "  "
+		name := normalStyle.Render(preset.Name)
+		if i == m.presetCursor {
+			cursor = iconPointer + " "
+			name = selectedStyle.Render(preset.Name)
+		}
+		desc := subtitleStyle.Render(fmt.Sprintf(" - %s → %s", strings.Join(preset.SourceList(), ", "), strings.ToLower(strings.TrimPrefix(preset.Format, "."))))
+		sb.WriteString(fmt.Sprintf("%s%s  %s%s\n", cursor, iconRocket, name, desc))
+	}
+
+	return borderStyle.Render(sb.String())
+}
+
 // viewSelectFile 渲染文件选择视图
 func (m model) viewSelectFile() string {
 	t := i18n.T()
 	var sb strings.Builder
 
-	if m.mode == modeExtract {
+	if m.pickingDir {
+		sb.WriteString(titleStyle.Render(t.SelectOutputDir))
+	} else if m.mode == modeExtract {
 		sb.WriteString(titleStyle.Render(t.SelectFileExtract))
 	} else {
 		sb.WriteString(titleStyle.Render(t.SelectFileCompress))
@@ -1364,6 +2437,10 @@ func (m model) viewSelectFile() string {
 			line = fmt.Sprintf("%s%s  %s %s", cursor, icon, name, sizeStr)
 		}
 
+		if slices.Contains(m.marked, entry.path) {
+			line += " " + successStyle.Render(iconCheck)
+		}
+
 		sb.WriteString(line)
 		sb.WriteString("\n")
 	}
@@ -1376,6 +2453,11 @@ func (m model) viewSelectFile() string {
 		sb.WriteString(scrollInfo)
 	}
 
+	if m.selectError != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(errorStyle.Render(iconError + " " + m.selectError))
+	}
+
 	return borderStyle.Render(sb.String())
 }
 
@@ -1386,7 +2468,7 @@ func (m model) viewSelectFormat() string {
 
 	sb.WriteString(titleStyle.Render(t.SelectFormat))
 	sb.WriteString("\n")
-	sb.WriteString(subtitleStyle.Render(t.SelectedFile + filepath.Base(m.selectedPath)))
+	sb.WriteString(subtitleStyle.Render(t.SelectedFile + m.sourceLabel()))
 	sb.WriteString("\n\n")
 
 	for i, format := range m.formats {
@@ -1418,7 +2500,25 @@ func (m model) viewSelectExcludes() string {
 	sb.WriteString(titleStyle.Render(t.SelectExcludes))
 	sb.WriteString("\n")
 	sb.WriteString(subtitleStyle.Render(t.ExcludeFormat + m.selectedFormat.Name + t.ToggleHint))
-	sb.WriteString("\n\n")
+	sb.WriteString("\n")
+	if len(m.detectedProjects) > 0 {
+		sb.WriteString(infoStyle.Render(fmt.Sprintf(t.DetectedProjects, strings.Join(m.detectedProjects, ", "))))
+		sb.WriteString("\n")
+	}
+	if m.excludeWarning != "" {
+		sb.WriteString(warningStyle.Render(iconWarning + " " + m.excludeWarning))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	if m.excludeScanning {
+		sb.WriteString(m.spinner.View() + " " + normalStyle.Render(t.ScanningExcludes))
+		return borderStyle.Render(sb.String())
+	}
+	if len(m.excludeCategories) == 0 {
+		sb.WriteString(disabledStyle.Render(t.NoExcludesFound))
+		return borderStyle.Render(sb.String())
+	}
 
 	for i, cat := range m.excludeCategories {
 		cursor := "  "
@@ -1442,14 +2542,187 @@ func (m model) viewSelectExcludes() string {
 			name = disabledStyle.Render(cat.Name)
 		}
 
-		// 显示部分模式
-		patterns := cat.Patterns
-		if len(patterns) > 3 {
-			patterns = patterns[:3]
+		// 显示部分匹配路径和可节省的空间，自定义类别显示模式
+		matches := cat.Matches
+		if cat.Custom {
+			matches = cat.Patterns
+		}
+		more := ""
+		if len(matches) > 3 {
+			matches = matches[:3]
+			more = ", ..."
+		}
+		patternsStr := subtitleStyle.Render(" (" + strings.Join(matches, ", ") + more + ")")
+		sizeStr := warningStyle.Render("  " + formatFileSize(cat.Size))
+
+		sb.WriteString(fmt.Sprintf("%s%s  %s%s%s\n", cursor, checkStyle.Render(checkbox), name, sizeStr, patternsStr))
+	}
+
+	return borderStyle.Render(sb.String())
+}
+
+// viewInputPattern 渲染自定义排除模式输入视图
+func (m model) viewInputPattern() string {
+	t := i18n.T()
+	var sb strings.Builder
+
+	if m.namingCategory {
+		sb.WriteString(titleStyle.Render(t.SaveCategoryTitle))
+		sb.WriteString("\n")
+		sb.WriteString(subtitleStyle.Render(strings.Join(m.excludeCategories[m.customCategory].Patterns, ", ")))
+	} else {
+		sb.WriteString(titleStyle.Render(t.AddPatternTitle))
+		sb.WriteString("\n")
+		sb.WriteString(subtitleStyle.Render(t.AddPatternHint))
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString(iconPointer + " " + infoStyle.Render(m.patternInput) + normalStyle.Render("█"))
+	sb.WriteString("\n\n")
+
+	switch {
+	case m.patternError != "":
+		sb.WriteString(errorStyle.Render(iconError + " " + m.patternError))
+	case m.namingCategory:
+	case m.patternInput == "":
+	case m.patternMatch == nil:
+		sb.WriteString(m.spinner.View() + " " + subtitleStyle.Render(t.PatternCounting))
+	default:
+		sb.WriteString(successStyle.Render(fmt.Sprintf(iconCheck+" "+t.PatternMatches, m.patternMatch.Files, formatFileSize(m.patternMatch.Size))))
+	}
+
+	return borderStyle.Render(sb.String())
+}
+
+// viewEditOutput 渲染输出位置编辑视图
+func (m model) viewEditOutput() string {
+	t := i18n.T()
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render(t.EditOutputTitle))
+	sb.WriteString("\n")
+	sb.WriteString(subtitleStyle.Render(t.EditOutputHint))
+	sb.WriteString("\n\n")
+
+	// 解压方式
+	if m.mode == modeExtract {
+		for _, mode := range config.ExtractModes {
+			if mode == m.extractMode {
+				sb.WriteString(iconPointer + " " + selectedStyle.Render(extractModeText(mode)))
+			} else {
+				sb.WriteString("  " + normalStyle.Render(extractModeText(mode)))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(iconFolderOpen + " " + infoStyle.Render(m.outputInput) + normalStyle.Render("█"))
+
+	if len(m.outputCandidates) > 0 {
+		sb.WriteString("\n\n")
+		candidates := m.outputCandidates
+		if len(candidates) > 8 {
+			candidates = append(candidates[:8:8], "...")
+		}
+		sb.WriteString(subtitleStyle.Render(strings.Join(candidates, "  ")))
+	}
+
+	if m.outputError != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(errorStyle.Render(iconError + " " + m.outputError))
+	}
+
+	return borderStyle.Render(sb.String())
+}
+
+// extractModeText 返回解压方式的显示名称
+func extractModeText(mode string) string {
+	t := i18n.T()
+	switch mode {
+	case config.ExtractModeFolder:
+		return t.ExtractIntoFolder
+	case config.ExtractModeHere:
+		return t.ExtractHere
+	default:
+		return t.ExtractSmart
+	}
+}
+
+// viewInputPresetName 渲染预设名称输入视图
+func (m model) viewInputPresetName() string {
+	t := i18n.T()
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render(t.SavePresetTitle))
+	sb.WriteString("\n")
+	sb.WriteString(subtitleStyle.Render(t.SavePresetHint))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(iconPointer + " " + infoStyle.Render(m.presetInput) + normalStyle.Render("█"))
+
+	if m.presetError != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(errorStyle.Render(iconError + " " + m.presetError))
+	}
+
+	return borderStyle.Render(sb.String())
+}
+
+// charsetDisplayName 返回编码的显示名称
+func charsetDisplayName(charset string) string {
+	switch charset {
+	case archiver.CharsetAuto:
+		return i18n.T().CharsetAuto
+	case archiver.CharsetUTF8:
+		return "UTF-8"
+	case archiver.CharsetGBK:
+		return "GBK / GB18030"
+	case archiver.CharsetBig5:
+		return "Big5"
+	case archiver.CharsetShiftJIS:
+		return "Shift-JIS"
+	case archiver.CharsetCP437:
+		return "CP437 (DOS)"
+	}
+	return charset
+}
+
+// viewSelectCharset 渲染文件名编码选择视图
+func (m model) viewSelectCharset() string {
+	t := i18n.T()
+	var sb strings.Builder
+
+	sb.WriteString(titleStyle.Render(t.SelectCharset))
+	sb.WriteString("\n")
+	sb.WriteString(subtitleStyle.Render(fmt.Sprintf(t.CharsetDetected, charsetDisplayName(m.detectedCharset))))
+	sb.WriteString("\n\n")
+
+	for i, charset := range archiver.ZipCharsets {
+		cursor := "  "
+		if i == m.charsetCursor {
+			cursor = iconPointer + " "
+		}
+
+		var name string
+		if i == m.charsetCursor {
+			name = selectedStyle.Render(charsetDisplayName(charset))
+		} else {
+			name = normalStyle.Render(charsetDisplayName(charset))
 		}
-		patternsStr := subtitleStyle.Render(" (" + strings.Join(patterns, ", ") + "...)")
+		sb.WriteString(fmt.Sprintf("%s%s\n", cursor, name))
+	}
 
-		sb.WriteString(fmt.Sprintf("%s%s  %s%s\n", cursor, checkStyle.Render(checkbox), name, patternsStr))
+	// 文件名预览
+	sb.WriteString("\n")
+	sb.WriteString(statLabelStyle.Render(t.CharsetPreview))
+	sb.WriteString("\n")
+	for _, name := range m.charsetPreview {
+		if runes := []rune(name); len(runes) > 60 {
+			name = "..." + string(runes[len(runes)-57:])
+		}
+		sb.WriteString(infoStyle.Render("  " + iconFile + "  " + name))
+		sb.WriteString("\n")
 	}
 
 	return borderStyle.Render(sb.String())
@@ -1538,6 +2811,10 @@ func (m model) viewInputPassword() string {
 
 // viewConfirm 渲染确认视图
 func (m model) viewConfirm() string {
+	if m.batch {
+		return m.viewBatchConfirm()
+	}
+
 	t := i18n.T()
 	var sb strings.Builder
 
@@ -1548,16 +2825,38 @@ func (m model) viewConfirm() string {
 	}
 	sb.WriteString("\n\n")
 
+	// 预设
+	if m.preset != nil {
+		sb.WriteString(statLabelStyle.Render(iconRocket + "  " + t.PresetLabel))
+		sb.WriteString(infoStyle.Render(m.preset.Name))
+		sb.WriteString("\n")
+	}
+
 	// 源文件
 	sb.WriteString(statLabelStyle.Render(iconFile + "  " + t.SourceFile))
-	sb.WriteString(statValueStyle.Render(filepath.Base(m.selectedPath)))
+	sb.WriteString(statValueStyle.Render(m.sourceLabel()))
 	sb.WriteString("\n")
 
 	// 输出
 	if m.mode == modeExtract {
 		sb.WriteString(statLabelStyle.Render(iconFolderOpen + "  " + t.ExtractTo))
-		sb.WriteString(statValueStyle.Render(filepath.Base(m.outputPath) + "/"))
+		sb.WriteString(statValueStyle.Render(m.displayOutputPath() + "/"))
 		sb.WriteString("\n")
+		sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.ExtractModeLabel))
+		sb.WriteString(infoStyle.Render(extractModeText(m.extractMode)))
+		sb.WriteString("\n")
+		if m.customTemplate() {
+			sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.OutputTemplate))
+			sb.WriteString(subtitleStyle.Render(m.outputTemplate))
+			sb.WriteString("\n")
+		}
+
+		// 文件名编码
+		if m.legacyNames {
+			sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.FilenameCharset))
+			sb.WriteString(infoStyle.Render(charsetDisplayName(archiver.ZipCharsets[m.charsetCursor])))
+			sb.WriteString("\n")
+		}
 
 		// 显示密码状态（解压模式）
 		format := archiver.DetectArchiveFormat(m.selectedPath)
@@ -1572,7 +2871,12 @@ func (m model) viewConfirm() string {
 		}
 	} else {
 		sb.WriteString(statLabelStyle.Render(iconArchive + "  " + t.OutputFile))
-		sb.WriteString(statValueStyle.Render(filepath.Base(m.outputPath)))
+		sb.WriteString(statValueStyle.Render(m.displayOutputPath()))
+		if m.customTemplate() {
+			sb.WriteString("\n")
+			sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.OutputTemplate))
+			sb.WriteString(subtitleStyle.Render(m.outputTemplate))
+		}
 	}
 	sb.WriteString("\n")
 
@@ -1595,9 +2899,13 @@ func (m model) viewConfirm() string {
 
 		// 排除规则数量
 		excludeCount := 0
-		for _, cat := range m.excludeCategories {
-			if cat.Selected {
-				excludeCount += len(cat.Patterns)
+		if m.preset != nil {
+			excludeCount = len(m.presetOpts.Excludes)
+		} else {
+			for _, cat := range m.excludeCategories {
+				if cat.Selected {
+					excludeCount += len(cat.Patterns)
+				}
 			}
 		}
 		sb.WriteString(statLabelStyle.Render(iconWarning + "  " + t.ExcludeRules))
@@ -1605,6 +2913,11 @@ func (m model) viewConfirm() string {
 		sb.WriteString("\n")
 	}
 
+	if m.presetSaved != "" {
+		sb.WriteString(successStyle.Render(iconCheck + " " + fmt.Sprintf(t.PresetSaved, m.presetSaved)))
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString("\n")
 	if m.mode == modeExtract {
 		sb.WriteString(successStyle.Render(t.ConfirmStartExtract))
@@ -1615,6 +2928,16 @@ func (m model) viewConfirm() string {
 	return highlightBorderStyle.Render(sb.String())
 }
 
+// displayOutputPath 返回相对于源文件所在目录的输出路径，不在该目录下时返回完整路径
+func (m model) displayOutputPath() string {
+	return relativeTo(m.sourceDir(), m.outputPath)
+}
+
+// customTemplate 判断输出是否由自定义命名模板生成
+func (m model) customTemplate() bool {
+	return m.outputTemplate != "" && m.outputTemplate != config.DefaultOutputTemplate && m.outputTemplate != config.DefaultExtractTemplate
+}
+
 // viewCompressing 渲染压缩中视图
 func (m model) viewCompressing() string {
 	t := i18n.T()
@@ -1759,6 +3082,10 @@ func (m model) viewExtracting() string {
 
 // viewDone 渲染完成视图
 func (m model) viewDone() string {
+	if m.batch {
+		return m.viewBatchDone()
+	}
+
 	t := i18n.T()
 	var sb strings.Builder
 
@@ -1768,8 +3095,12 @@ func (m model) viewDone() string {
 
 		// 输出目录
 		sb.WriteString(statLabelStyle.Render(iconFolderOpen + "  " + t.ExtractToLabel))
-		sb.WriteString(statValueStyle.Render(filepath.Base(m.outputPath) + "/"))
+		sb.WriteString(statValueStyle.Render(m.displayOutputPath() + "/"))
 		sb.WriteString("\n")
+		if m.extractStats.FlattenedDir != "" {
+			sb.WriteString(subtitleStyle.Render("  " + fmt.Sprintf(t.FlattenedDir, m.extractStats.FlattenedDir)))
+			sb.WriteString("\n")
+		}
 
 		// 解压文件数
 		sb.WriteString(statLabelStyle.Render(iconFile + "  " + t.ExtractedFiles))
@@ -1780,13 +3111,29 @@ func (m model) viewDone() string {
 		sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.ExtractedSize))
 		sb.WriteString(successStyle.Render(formatFileSize(m.extractStats.ExtractedSize)))
 		sb.WriteString("\n")
+
+		// 重命名的条目
+		if len(m.extractStats.Renamed) > 0 {
+			sb.WriteString(statLabelStyle.Render(iconWarning + "  " + t.RenamedEntries))
+			sb.WriteString(warningStyle.Render(fmt.Sprintf("%d", len(m.extractStats.Renamed))))
+			sb.WriteString("\n")
+			for i, r := range m.extractStats.Renamed {
+				if i >= 8 {
+					sb.WriteString(subtitleStyle.Render(fmt.Sprintf("  "+t.RenamedMore, len(m.extractStats.Renamed)-i)))
+					sb.WriteString("\n")
+					break
+				}
+				sb.WriteString(subtitleStyle.Render(fmt.Sprintf("  %s → %s (%s)", r.Original, r.Renamed, renameReasonText(r.Reasons))))
+				sb.WriteString("\n")
+			}
+		}
 	} else {
 		sb.WriteString(successStyle.Render(iconSuccess + "  " + t.CompressDone))
 		sb.WriteString("\n\n")
 
 		// 输出文件
 		sb.WriteString(statLabelStyle.Render(iconArchive + "  " + t.OutputFileLabel))
-		sb.WriteString(statValueStyle.Render(filepath.Base(m.outputPath)))
+		sb.WriteString(statValueStyle.Render(m.displayOutputPath()))
 		sb.WriteString("\n")
 
 		// 压缩文件数
@@ -1809,6 +3156,20 @@ func (m model) viewDone() string {
 		sb.WriteString(successStyle.Render(fmt.Sprintf("%.1f%%", m.compressStats.CompressionRate)))
 		sb.WriteString("\n")
 
+		// Zstd 字典的效果
+		if d := m.compressStats.ZstdDict; d != nil {
+			sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.ZstdDictStat))
+			sb.WriteString(infoStyle.Render(zstdDictSummary(*d)))
+			sb.WriteString("\n")
+		}
+
+		// 压缩时建立的 Gzip 索引
+		if ix := m.compressStats.GzipIndex; ix != nil {
+			sb.WriteString(statLabelStyle.Render(iconInfo + "  " + t.GzipIndexStat))
+			sb.WriteString(infoStyle.Render(gzipIndexSummary(*ix)))
+			sb.WriteString("\n")
+		}
+
 		// 排除文件数
 		if m.compressStats.ExcludedFiles > 0 {
 			sb.WriteString(statLabelStyle.Render(iconWarning + "  " + t.ExcludedFiles))
@@ -1820,6 +3181,43 @@ func (m model) viewDone() string {
 	return highlightBorderStyle.Render(sb.String())
 }
 
+// zstdDictSummary 字典大小、使用与不使用字典的压缩大小对比及是否保留
+func zstdDictSummary(d archiver.DictStats) string {
+	if d.Size == 0 {
+		return fmt.Sprintf(i18n.T().ZstdDictFewSamples, d.Samples)
+	}
+	status := i18n.T().ZstdDictUsed
+	if !d.Used {
+		status = i18n.T().ZstdDictDiscarded
+	}
+	return fmt.Sprintf("%s · %s → %s (%+.1f%%) · %s",
+		formatFileSize(int64(d.Size)), formatFileSize(d.WithoutDict), formatFileSize(d.WithDict), -d.Saving(), status)
+}
+
+// gzipIndexSummary 索引文件名和大小
+func gzipIndexSummary(ix archiver.GzipIndexStats) string {
+	return fmt.Sprintf("%s · %s", filepath.Base(ix.Path), formatFileSize(ix.Size))
+}
+
+// renameReasonText 返回重命名原因的显示文本，多个原因用逗号分隔
+func renameReasonText(reasons []string) string {
+	t := i18n.T()
+	texts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		switch reason {
+		case archiver.RenameNFC:
+			texts[i] = t.RenameNFC
+		case archiver.RenameSanitize:
+			texts[i] = t.RenameSanitize
+		case archiver.RenameCollision:
+			texts[i] = t.RenameCollision
+		default:
+			texts[i] = reason
+		}
+	}
+	return strings.Join(texts, ", ")
+}
+
 // viewError 渲染错误视图
 func (m model) viewError() string {
 	t := i18n.T()
@@ -1860,10 +3258,29 @@ func formatFileSize(size int64) string {
 }
 
 func main() {
-	// 初始化国际化，根据系统语言自动选择
-	i18n.Init()
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	// 读取用户配置文件
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// 初始化国际化，未配置语言时根据系统语言自动选择
+	switch cfg.Language {
+	case "en":
+		i18n.SetLanguage(i18n.LangEN)
+	case "zh":
+		i18n.SetLanguage(i18n.LangZH)
+	default:
+		i18n.Init()
+	}
+	applyTheme(cfg.Theme, cfg.Colors)
 
-	p := tea.NewProgram(newModel(), tea.WithAltScreen())
+	p := tea.NewProgram(newModel(cfg), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Failed to start: %v\n", err)
 		os.Exit(1)
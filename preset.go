@@ -0,0 +1,140 @@
+package main
+
+import (
+	"archive/tar"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Lynricsy/SimpleArchiver/internal/archiver"
+	"github.com/Lynricsy/SimpleArchiver/internal/config"
+)
+
+// tarFormats 预设中 tar_format 的取值
+var tarFormats = map[string]tar.Format{
+	"ustar": tar.FormatUSTAR,
+	"pax":   tar.FormatPAX,
+	"gnu":   tar.FormatGNU,
+}
+
+// presetOptions 将预设转换为压缩选项，相对时间以 now 为基准
+func presetOptions(p config.Preset, cfg *config.Config, now time.Time) (archiver.CompressOptions, error) {
+	var opts archiver.CompressOptions
+
+	i := p.FormatIndex()
+	if i < 0 {
+		return opts, fmt.Errorf("预设 %q 的格式无效: %q", p.Name, p.Format)
+	}
+	format := config.GetArchiveFormats()[i]
+
+	var sources []string
+	for _, path := range p.SourceList() {
+		source, err := filepath.Abs(config.ExpandHome(path))
+		if err != nil {
+			return opts, fmt.Errorf("解析源路径失败: %w", err)
+		}
+		sources = append(sources, source)
+	}
+	if err := archiver.CheckSources(sources); err != nil {
+		return opts, fmt.Errorf("预设 %q: %w", p.Name, err)
+	}
+
+	// 多个源时以共同上级目录命名输出，输出也放在该目录中
+	source, sourceDir := sources[0], filepath.Dir(sources[0])
+	if len(sources) > 1 {
+		source = commonDir(sources)
+		sourceDir = source
+	} else {
+		sources = nil
+	}
+
+	template := p.Output
+	if template == "" {
+		template = cfg.CompressNameTemplate()
+	}
+	dir := cfg.OutputDir()
+	if dir == "" {
+		dir = sourceDir
+	}
+	vars := archiver.NewOutputVars(source, filepath.Base(source), format.Extension, now)
+	output, err := archiver.ResolveOutputPath(config.ExpandHome(template), dir, vars)
+	if err != nil {
+		return opts, fmt.Errorf("生成预设 %q 的输出路径失败: %w", p.Name, err)
+	}
+
+	minSize, err := config.ParseSize(p.MinSize)
+	if err != nil {
+		return opts, err
+	}
+	maxSize, err := config.ParseSize(p.MaxSize)
+	if err != nil {
+		return opts, err
+	}
+	newerThan, err := config.ParseTime(p.NewerThan, now)
+	if err != nil {
+		return opts, err
+	}
+	olderThan, err := config.ParseTime(p.OlderThan, now)
+	if err != nil {
+		return opts, err
+	}
+
+	opts = archiver.CompressOptions{
+		Source:             source,
+		Sources:            sources,
+		Output:             output,
+		Format:             format.Extension,
+		Excludes:           p.Excludes,
+		Includes:           p.Includes,
+		Level:              p.Level,
+		RespectIgnoreFiles: p.RespectIgnoreFiles,
+		MinSize:            minSize,
+		MaxSize:            maxSize,
+		NewerThan:          newerThan,
+		OlderThan:          olderThan,
+		FileTypes:          p.FileTypes,
+		SkipBinary:         p.SkipBinary,
+		Reproducible:       p.Reproducible,
+		TarFormat:          tarFormats[strings.ToLower(p.TarFormat)],
+		ArchivePrefix:      p.ArchivePrefix,
+	}
+	compressTuning(cfg, &opts)
+	return opts, nil
+}
+
+// presetFromModel 将当前的选择保存为预设，运行中的预设会以新名称复制
+func (m model) presetFromModel(name string) config.Preset {
+	if m.preset != nil {
+		p := *m.preset
+		p.Name = name
+		if m.outputTemplate == "" {
+			p.Output = m.outputPath
+		}
+		return p
+	}
+
+	excludes, respectIgnoreFiles := m.selectedExcludes()
+
+	// 输出由模板生成时保存模板，每次运行重新渲染日期、序号等变量
+	output := m.outputPath
+	if m.outputTemplate != "" {
+		output = m.outputTemplate
+	}
+
+	source := m.selectedPath
+	if len(m.selectedPaths) > 0 {
+		source = ""
+	}
+
+	return config.Preset{
+		Name:               name,
+		Source:             source,
+		Sources:            m.selectedPaths,
+		Format:             strings.TrimPrefix(m.selectedFormat.Extension, "."),
+		Output:             output,
+		Level:              m.cfg.Defaults.Level,
+		Excludes:           excludes,
+		RespectIgnoreFiles: respectIgnoreFiles,
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Lynricsy/SimpleArchiver/internal/archiver"
+	"github.com/Lynricsy/SimpleArchiver/internal/config"
+)
+
+// loadConfig 读取用户配置文件，并校验依赖归档实现的取值范围
+func loadConfig() (*config.Config, error) {
+	cfg, path, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("配置文件 %s 无效:\n%w", path, err)
+	}
+	return cfg, nil
+}
+
+// validateConfig 校验模板、并行数和流参数，大小的格式已由 config.Validate 检查
+func validateConfig(cfg *config.Config) error {
+	var errs []error
+	d := cfg.Defaults
+
+	for _, field := range []struct{ key, value string }{{"name_template", d.NameTemplate}, {"extract_template", d.ExtractTemplate}} {
+		if field.value == "" {
+			continue
+		}
+		if err := archiver.ValidateOutputTemplate(field.value); err != nil {
+			errs = append(errs, fmt.Errorf("  defaults.%s: %w", field.key, err))
+		}
+	}
+	if err := archiver.ValidateBatchWorkers(d.BatchWorkers); err != nil {
+		errs = append(errs, fmt.Errorf("  defaults.batch_workers: %w", err))
+	}
+	if err := archiver.ValidateWorkers(d.Workers); err != nil {
+		errs = append(errs, fmt.Errorf("  defaults.workers: %w", err))
+	}
+	gzipBlockSize, _ := config.ParseSize(d.GzipBlockSize)
+	if err := archiver.ValidateGzipBlockSize(int(gzipBlockSize)); err != nil {
+		errs = append(errs, fmt.Errorf("  defaults.gzip_block_size: %w", err))
+	}
+	zstdWindowSize, _ := config.ParseSize(d.ZstdWindowSize)
+	if err := archiver.ValidateZstdWindowSize(int(zstdWindowSize)); err != nil {
+		errs = append(errs, fmt.Errorf("  defaults.zstd_window_size: %w", err))
+	}
+	if err := archiver.ValidateZstdDict(d.ZstdDict); err != nil {
+		errs = append(errs, fmt.Errorf("  defaults.zstd_dict: %w", err))
+	}
+	zstdFrameSize, _ := config.ParseSize(d.ZstdFrameSize)
+	if err := archiver.ValidateZstdFrameSize(int(zstdFrameSize)); err != nil {
+		errs = append(errs, fmt.Errorf("  defaults.zstd_frame_size: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// compressTuning 将配置中的 Gzip / Zstd 流参数和并行数填入压缩选项，配置已在加载时校验
+func compressTuning(cfg *config.Config, opts *archiver.CompressOptions) {
+	gzipBlockSize, _ := config.ParseSize(cfg.Defaults.GzipBlockSize)
+	zstdWindowSize, _ := config.ParseSize(cfg.Defaults.ZstdWindowSize)
+	zstdFrameSize, _ := config.ParseSize(cfg.Defaults.ZstdFrameSize)
+	opts.Workers = cfg.Defaults.Workers
+	opts.GzipBlockSize = int(gzipBlockSize)
+	opts.GzipIndex = cfg.Defaults.GzipIndex
+	opts.ZstdWindowSize = int(zstdWindowSize)
+	opts.ZstdLong = cfg.Defaults.ZstdLong
+	opts.ZstdDict = cfg.Defaults.ZstdDict
+	opts.ZstdSeekable = cfg.Defaults.ZstdSeekable
+	opts.ZstdFrameSize = int(zstdFrameSize)
+}
+
+// extractTuning 将配置中的 Gzip / Zstd 流参数和并行数填入解压选项
+func extractTuning(cfg *config.Config, opts *archiver.ExtractOptions) {
+	gzipBlockSize, _ := config.ParseSize(cfg.Defaults.GzipBlockSize)
+	zstdMaxMemory, _ := config.ParseSize(cfg.Defaults.ZstdMaxMemory)
+	opts.Workers = cfg.Defaults.Workers
+	opts.GzipBlockSize = int(gzipBlockSize)
+	opts.ZstdMaxMemory = uint64(zstdMaxMemory)
+}
+
+// batchWorkerCount 返回批量任务的并发数
+func batchWorkerCount(cfg *config.Config) int {
+	if cfg.Defaults.BatchWorkers == 0 {
+		return archiver.DefaultBatchWorkers()
+	}
+	return cfg.Defaults.BatchWorkers
+}
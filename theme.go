@@ -0,0 +1,85 @@
+package main
+
+import "github.com/charmbracelet/lipgloss"
+
+// themePalette 主题配色
+type themePalette struct {
+	primary, secondary, success, warning, errorC, muted, foreground, border, archive lipgloss.Color
+	statusDark, statusMid, statusAccent                                              lipgloss.Color
+}
+
+// themes 内置主题
+var themes = map[string]themePalette{
+	"dark": {
+		primary:      "#7C3AED",
+		secondary:    "#06B6D4",
+		success:      "#10B981",
+		warning:      "#F59E0B",
+		errorC:       "#EF4444",
+		muted:        "#6B7280",
+		foreground:   "#F9FAFB",
+		border:       "#374151",
+		archive:      "#EC4899",
+		statusDark:   "#1F2937",
+		statusMid:    "#374151",
+		statusAccent: "#4B5563",
+	},
+	"light": {
+		primary:      "#6D28D9",
+		secondary:    "#0E7490",
+		success:      "#047857",
+		warning:      "#B45309",
+		errorC:       "#B91C1C",
+		muted:        "#6B7280",
+		foreground:   "#111827",
+		border:       "#D1D5DB",
+		archive:      "#BE185D",
+		statusDark:   "#E5E7EB",
+		statusMid:    "#D1D5DB",
+		statusAccent: "#9CA3AF",
+	},
+}
+
+func init() {
+	initStyles()
+}
+
+// applyTheme 切换主题并应用配置中覆盖的颜色，空名称使用 dark
+func applyTheme(name string, overrides map[string]string) {
+	palette, ok := themes[name]
+	if !ok {
+		palette = themes["dark"]
+	}
+
+	primaryColor = palette.primary
+	secondaryColor = palette.secondary
+	successColor = palette.success
+	warningColor = palette.warning
+	errorColor = palette.errorC
+	mutedColor = palette.muted
+	foregroundColor = palette.foreground
+	borderColor = palette.border
+	archiveColor = palette.archive
+	statusBgDark = palette.statusDark
+	statusBgMid = palette.statusMid
+	statusBgAccent = palette.statusAccent
+
+	colors := map[string]*lipgloss.Color{
+		"primary":    &primaryColor,
+		"secondary":  &secondaryColor,
+		"success":    &successColor,
+		"warning":    &warningColor,
+		"error":      &errorColor,
+		"muted":      &mutedColor,
+		"foreground": &foregroundColor,
+		"border":     &borderColor,
+		"archive":    &archiveColor,
+	}
+	for key, value := range overrides {
+		if color, ok := colors[key]; ok {
+			*color = lipgloss.Color(value)
+		}
+	}
+
+	initStyles()
+}